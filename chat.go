@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	//chatScrollback is how many lines the pane remembers, chatVisibleLines how many it shows
+	chatScrollback   = 100
+	chatVisibleLines = 12
+	chatWidth        = 36
+)
+
+type chatLine struct {
+	from string
+	text string
+	mine bool
+}
+
+// chatPane - The chat shown beside the board while in a room. Keys only go to the input box while
+// it is focused, so the game's movement keys keep working with the pane open.
+type chatPane struct {
+	lines  []chatLine
+	open   bool
+	unread int
+	input  textinput.Model
+}
+
+func newChatPane() chatPane {
+	input := textinput.New()
+	input.Placeholder = "Say something"
+	input.CharLimit = messages.MaxChatLength
+	input.Width = chatWidth - 4
+	return chatPane{input: input}
+}
+
+// chatEnabled - The screens that are in a room with an opponent to talk to.
+func chatEnabled(stateType SessionStateType) bool {
+	switch stateType {
+	case SessionStateTypeWaitingRoom, SessionStateTypeGameSelection, SessionStateTypeInGame, SessionStateTypeEndGame:
+		return true
+	}
+	return false
+}
+
+// add - Keeps a message, dropping the oldest once the scrollback is full. The server already
+// cleans up messages, but they are cleaned again in case it is not a server we trust.
+func (chat *chatPane) add(from string, text string, mine bool) {
+	text = messages.SanitizeChat(text)
+	if text == "" {
+		return
+	}
+	chat.lines = append(chat.lines, chatLine{from: messages.SanitizeChat(from), text: text, mine: mine})
+	if len(chat.lines) > chatScrollback {
+		chat.lines = chat.lines[len(chat.lines)-chatScrollback:]
+	}
+	if !chat.open {
+		chat.unread++
+	}
+}
+
+// handleKey - tab opens the pane and moves the keyboard between the game and the input box,
+// shift+tab hides the pane. While typing, enter sends and esc goes back to the game. Reports
+// whether the key was used up by the chat.
+func (chat *chatPane) handleKey(msg tea.KeyMsg, session Session) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "tab":
+		if chat.input.Focused() {
+			chat.input.Blur()
+			return true, nil
+		}
+		chat.open = true
+		chat.unread = 0
+		return true, chat.input.Focus()
+	case "shift+tab":
+		chat.open = false
+		chat.input.Blur()
+		return true, nil
+	}
+	if !chat.input.Focused() {
+		return false, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		chat.input.Blur()
+		return true, nil
+	case "enter":
+		text := messages.SanitizeChat(chat.input.Value())
+		chat.input.Reset()
+		chat.input.Blur()
+		if text == "" {
+			return true, nil
+		}
+		return true, session.SendMsgToServer(messages.ClientMessage{Type: messages.ClientChat, Text: text})
+	}
+	var cmd tea.Cmd
+	chat.input, cmd = chat.input.Update(msg)
+	return true, cmd
+}
+
+// render - The latest messages above the input box, or a one line hint while the pane is closed.
+func (chat chatPane) render() string {
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Padding(0, 1)
+	if !chat.open {
+		if chat.unread > 0 {
+			return hintStyle.Foreground(lipgloss.Color("#FF9500")).Render(fmt.Sprintf("💬 %d new • tab Chat", chat.unread))
+		}
+		return hintStyle.Render("tab Chat")
+	}
+
+	paneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(0, 1).
+		Width(chatWidth)
+	nameStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF6B35"))
+	myNameStyle := nameStyle.Foreground(lipgloss.Color("#7D56F4"))
+	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA")).Width(chatWidth - 2)
+
+	lines := []string{}
+	for _, line := range chat.lines {
+		name := nameStyle.Render(line.from + ":")
+		if line.mine {
+			name = myNameStyle.Render(line.from + ":")
+		}
+		lines = append(lines, strings.Split(textStyle.Render(name+" "+line.text), "\n")...)
+	}
+	if len(lines) > chatVisibleLines {
+		lines = lines[len(lines)-chatVisibleLines:]
+	}
+	for len(lines) < chatVisibleLines {
+		lines = append([]string{""}, lines...)
+	}
+
+	controls := "tab Type • shift+tab Hide"
+	if chat.input.Focused() {
+		controls = "enter Send • esc Back to game"
+	}
+	return paneStyle.Render(lipgloss.JoinVertical(lipgloss.Left,
+		strings.Join(lines, "\n"),
+		chat.input.View(),
+		hintStyle.Padding(0).Render(controls),
+	))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+// how often running clocks are redrawn
+const clockRefresh = 200 * time.Millisecond
+
+// ClockTickMsg - Redraws the screen while a clock is running.
+type ClockTickMsg struct{}
+
+func clockTick() tea.Cmd {
+	return tea.Tick(clockRefresh, func(time.Time) tea.Msg {
+		return ClockTickMsg{}
+	})
+}
+
+// timeControlPresets - The clocks a new room can be created with, cycled through in the menu.
+var timeControlPresets = []messages.TimeControl{
+	{},
+	{Base: time.Minute},
+	{Base: 3 * time.Minute, Increment: 2 * time.Second},
+	{Base: 5 * time.Minute, Increment: 5 * time.Second},
+	{Base: 10 * time.Minute, Increment: 5 * time.Second},
+	{PerMove: 30 * time.Second},
+}
+
+// gameClocks - Both players' clocks as the room last reported them. The room's message is the only
+// source of truth, and the clock of the player to move is run down locally from when it arrived.
+type gameClocks struct {
+	control   messages.TimeControl
+	remaining [2]time.Duration
+	//running is whose clock is counting down, 0 when neither is
+	running  int
+	received time.Time
+	//timedOut is the player who lost on time, if the game ended that way
+	timedOut int
+}
+
+// update - Takes the clocks from a room message. Only messages that say whose turn it is can start
+// or move the running clock, and a finished game stops it.
+func (clocks gameClocks) update(msg messages.ServerMessage) gameClocks {
+	clocks.control = msg.TimeControl
+	clocks.remaining = msg.Clocks
+	clocks.received = time.Now()
+	switch msg.Type {
+	case messages.ServerGameStarted, messages.ServerTurnResult:
+		clocks.running = msg.PlayerTurn
+		clocks.timedOut = 0
+	case messages.ServerResumed, messages.ServerSpectating:
+		clocks.running = 0
+		if msg.Phase == messages.RoomPhaseInGame {
+			clocks.running = msg.PlayerTurn
+		}
+	case messages.ServerGameFinished:
+		clocks.running = 0
+		clocks.timedOut = msg.TimedOutPlayerNum
+	}
+	if !clocks.control.IsTimed() {
+		clocks.running = 0
+	}
+	return clocks
+}
+
+func (clocks gameClocks) left(playerNumber int) time.Duration {
+	left := clocks.remaining[playerNumber-1]
+	if clocks.running == playerNumber {
+		left -= time.Since(clocks.received)
+	}
+	return max(left, 0)
+}
+
+// render - A line with both clocks, the running one highlighted. Untimed games show nothing.
+func (clocks gameClocks) render(names playerNames) string {
+	if !clocks.control.IsTimed() {
+		return ""
+	}
+
+	idleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Background(lipgloss.Color("#1C1C1E")).
+		Padding(0, 1)
+	runningStyle := idleStyle.
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4"))
+	lowStyle := runningStyle.Background(lipgloss.Color("#FF3B30"))
+
+	parts := []string{}
+	for playerNumber := 1; playerNumber <= 2; playerNumber++ {
+		left := clocks.left(playerNumber)
+		style := idleStyle
+		switch {
+		case clocks.running == playerNumber && left < 10*time.Second:
+			style = lowStyle
+		case clocks.running == playerNumber:
+			style = runningStyle
+		}
+		parts = append(parts, style.Render(fmt.Sprintf("⏱ %v %v", names.name(playerNumber), formatClock(left))))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, parts[0], " ", parts[1], " ", idleStyle.Render(clocks.control.String()))
+}
+
+// formatClock - Minutes and seconds, with tenths once a clock is nearly out.
+func formatClock(left time.Duration) string {
+	if left < 10*time.Second {
+		return fmt.Sprintf("0:%04.1f", left.Seconds())
+	}
+	left = left.Truncate(time.Second)
+	return fmt.Sprintf("%d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
+
+func (clocks *gameClocks) setClocks(latest gameClocks) {
+	*clocks = latest
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config - Client settings kept between runs, in the user's config directory.
+type Config struct {
+	Nickname string `json:"nickname"`
+	//set while logged in, the token stands in for the password
+	Username     string `json:"username"`
+	SessionToken string `json:"session_token"`
+	//MuteEmotes hides the opponent's emotes
+	MuteEmotes bool `json:"mute_emotes"`
+	//Bell and Notification are how to get the player's attention when they are needed in a game
+	Bell         bool              `json:"bell"`
+	Notification NotificationStyle `json:"notification"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding config directory: %w", err)
+	}
+	return filepath.Join(dir, "ascii-arcade", "config.json"), nil
+}
+
+// LoadConfig - Reads the saved settings. A first run has none yet, which is not an error.
+func LoadConfig() (Config, error) {
+	var config Config
+	path, err := configPath()
+	if err != nil {
+		return config, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("error reading config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing config %v: %w", path, err)
+	}
+	return config, nil
+}
+
+// Save - Writes the settings out. The session token stands in for the password, so only the user
+// may read the file, and files from before it was kept are locked down too.
+func (config Config) Save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return fmt.Errorf("error securing config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	//WriteFile only sets the mode of a new file
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("error securing config: %w", err)
+	}
+	return nil
+}
+
+// loggedInAs - The account the client is logged in as. A remembered username without a session
+// token is only a prefill for logging back in.
+func (config Config) loggedInAs() string {
+	if config.SessionToken == "" {
+		return ""
+	}
+	return config.Username
+}
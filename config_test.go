@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	config, err := LoadConfig()
+	if err != nil || config != (Config{}) {
+		t.Fatalf("expected an empty config on first run, got %+v and %v", config, err)
+	}
+
+	if err := (Config{Nickname: "Ada"}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	config, err = LoadConfig()
+	if err != nil || config.Nickname != "Ada" {
+		t.Errorf("expected the saved nickname back, got %+v and %v", config, err)
+	}
+}
+
+func TestConfigSaveKeepsTokenPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not apply on windows")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	//a config saved by an older version is readable by everyone
+	path := filepath.Join(dir, "ascii-arcade", "config.json")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, []byte("{}"), 0o644)
+
+	if err := (Config{Username: "ada", SessionToken: "secret"}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string]os.FileMode{path: 0o600, filepath.Dir(path): 0o700} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != expected {
+			t.Errorf("expected %v to be %v, got %v", file, expected, info.Mode().Perm())
+		}
+	}
+}
+
+func TestLoadConfigReportsCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "ascii-arcade", "config.json")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, []byte("{not json"), 0o644)
+
+	if _, err := LoadConfig(); err == nil {
+		t.Error("expected an error for a corrupt config file")
+	}
+}
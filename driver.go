@@ -0,0 +1,12 @@
+package main
+
+import "github.com/wbarthol/ascii-arcade-2/internal/messages"
+
+// Driver - Carries messages between the session and whatever is running the room, whether that is
+// the remote server or a room running in this process. Run pushes server messages onto the
+// session's driverToSession channel and closes it when the driver stops.
+type Driver interface {
+	WriteToServer(msg messages.ClientMessage) error
+	Run()
+	Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+// how long an emote stays on screen
+const emoteBannerDuration = 3 * time.Second
+
+// EmoteExpiredMsg - Takes down the emote banner, unless a newer emote has replaced it since.
+type EmoteExpiredMsg struct {
+	id int
+}
+
+// emoteBanner - The latest emote on screen. id tells apart emotes sent one after another.
+type emoteBanner struct {
+	text string
+	id   int
+}
+
+// emotesEnabled - The screens where players can react to the game.
+func emotesEnabled(stateType SessionStateType) bool {
+	return stateType == SessionStateTypeInGame || stateType == SessionStateTypeEndGame
+}
+
+// handleEmoteKey - Number keys send the matching emote and m mutes the opponent's. Reports whether
+// the key was used up.
+func (session Session) handleEmoteKey(msg tea.KeyMsg) (Session, bool, tea.Cmd) {
+	if msg.String() == "m" {
+		session.config.MuteEmotes = !session.config.MuteEmotes
+		if session.config.MuteEmotes {
+			session.emote = emoteBanner{id: session.emote.id}
+		}
+		if err := session.config.Save(); err != nil {
+			return session, true, func() tea.Msg { return ErrMsg{err} }
+		}
+		return session, true, nil
+	}
+
+	number, err := strconv.Atoi(msg.String())
+	emotes := messages.Emotes()
+	if err != nil || number < 1 || number > len(emotes) {
+		return session, false, nil
+	}
+	return session, true, session.SendMsgToServer(messages.ClientMessage{
+		Type:  messages.ClientEmote,
+		Emote: emotes[number-1],
+	})
+}
+
+// showEmote - Puts an emote up as a banner and returns the command that takes it down. The
+// opponent's emotes are not shown while muted, but the player's own always are.
+func (session Session) showEmote(msg messages.ServerMessage) (Session, tea.Cmd) {
+	mine := msg.PlayerNumber == session.playerNumber
+	if !msg.Emote.IsValid() || session.config.MuteEmotes && !mine {
+		return session, nil
+	}
+
+	from := playerNames(session.playerNames).name(msg.PlayerNumber)
+	if mine {
+		from = "You"
+	}
+	session.emote = emoteBanner{
+		text: fmt.Sprintf("%v: %v!", from, msg.Emote),
+		id:   session.emote.id + 1,
+	}
+	id := session.emote.id
+	return session, tea.Tick(emoteBannerDuration, func(time.Time) tea.Msg {
+		return EmoteExpiredMsg{id: id}
+	})
+}
+
+// emoteControls - The key for each emote, and whether they are muted.
+func emoteControls(muted bool) string {
+	keys := []string{}
+	for i, emote := range messages.Emotes() {
+		keys = append(keys, fmt.Sprintf("%d %v", i+1, emote))
+	}
+	mute := "m Mute"
+	if muted {
+		mute = "m Unmute"
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Padding(0, 1).
+		Render(strings.Join(keys, " • ") + " • " + mute)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	//the client pings often enough to keep the latency shown up to date, and a server that has not
+	//answered in heartbeatTimeout is taken to be gone
+	heartbeatInterval = 5 * time.Second
+	heartbeatTimeout  = 10 * time.Second
+)
+
+// LatencyMsg - The round trip time of the latest ping to the server.
+type LatencyMsg struct {
+	latency time.Duration
+}
+
+// formatLatency - Whole milliseconds, which is as precise as a round trip is worth showing.
+func formatLatency(latency time.Duration) string {
+	return fmt.Sprintf("%dms", max(latency.Milliseconds(), 1))
+}
+
+func heartbeatDeadline() time.Time {
+	return time.Now().Add(heartbeatInterval + heartbeatTimeout)
+}
+
+// watchConnection - Keeps a connection's heartbeat going until it is closed. The server's pings are
+// answered, and the client's own carry the time they were sent so the pong gives the round trip.
+// Any sign of life from the server pushes the read deadline back, and missing them all makes the
+// next read fail, which Run treats like any other dropped connection.
+func (driver *WSDriver) watchConnection(conn *websocket.Conn) {
+	conn.SetReadDeadline(heartbeatDeadline())
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(heartbeatDeadline())
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(heartbeatTimeout))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+	conn.SetPongHandler(func(data string) error {
+		if sent, err := strconv.ParseInt(data, 10, 64); err == nil {
+			driver.reportLatency(time.Since(time.Unix(0, sent)))
+		}
+		return conn.SetReadDeadline(heartbeatDeadline())
+	})
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sent := strconv.FormatInt(time.Now().UnixNano(), 10)
+				if err := conn.WriteControl(websocket.PingMessage, []byte(sent), time.Now().Add(heartbeatTimeout)); err != nil {
+					//a closed connection, which Run or a reconnect is already dealing with
+					return
+				}
+			case <-driver.done:
+				return
+			}
+		}
+	}()
+}
+
+// reportLatency - Passes the latency on without waiting, since it is called from inside a read and
+// a missed update is soon replaced by the next one.
+func (driver *WSDriver) reportLatency(latency time.Duration) {
+	select {
+	case driver.driverLatency <- latency:
+	default:
+	}
+}
@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"errors"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+var ErrNoValidTurns = errors.New("no valid turns available")
+
+// Bot - Anything that can pick a turn for a player given the current game.
+type Bot interface {
+	SelectTurn(g game.Game, playerNum int) (game.GameTurn, error)
+}
+
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyMedium
+	DifficultyHard
+)
+
+func GetDifficulties() []Difficulty {
+	return []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+}
+
+func (difficulty Difficulty) String() string {
+	switch difficulty {
+	case DifficultyEasy:
+		return "Easy"
+	case DifficultyMedium:
+		return "Medium"
+	case DifficultyHard:
+		return "Hard"
+	default:
+		return "Unknown"
+	}
+}
+
+// searchSettings - How deep the bot looks, how long it may think, and how often it blunders on purpose.
+type searchSettings struct {
+	maxDepth    int
+	timeLimit   time.Duration
+	mistakeRate float64
+}
+
+func (difficulty Difficulty) searchSettings() searchSettings {
+	switch difficulty {
+	case DifficultyEasy:
+		return searchSettings{maxDepth: 1, timeLimit: 250 * time.Millisecond, mistakeRate: 0.35}
+	case DifficultyMedium:
+		return searchSettings{maxDepth: 4, timeLimit: time.Second, mistakeRate: 0.1}
+	default:
+		return searchSettings{maxDepth: 10, timeLimit: 2 * time.Second, mistakeRate: 0}
+	}
+}
+
+func (difficulty Difficulty) mctsOptions() MCTSOptions {
+	switch difficulty {
+	case DifficultyEasy:
+		return MCTSOptions{Playouts: 200}
+	case DifficultyMedium:
+		return MCTSOptions{Playouts: 5_000, TimeBudget: time.Second}
+	default:
+		return MCTSOptions{TimeBudget: 2 * time.Second}
+	}
+}
+
+// NewBot - Returns the strongest bot available for the game type at the given difficulty. Games
+// with a hand-tuned evaluator get alpha-beta search, and every other game falls back to MCTS.
+// Tic-tac-toe and checkers both have evaluators, so MCTS is only there for games added without
+// one and is not picked for any game today.
+func NewBot(gameType game.GameType, difficulty Difficulty) Bot {
+	evaluate := GetEvaluator(gameType)
+	if evaluate == nil {
+		return NewMCTSBot(difficulty.mctsOptions())
+	}
+	return NewAlphaBetaBot(evaluate, difficulty)
+}
+
+func otherPlayer(playerNum int) int {
+	if playerNum == 1 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,158 @@
+package ai
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+const (
+	winScore = 1_000_000
+	//any score this close to winScore is a forced result, so deeper searches cannot change it
+	forcedResultMargin = 1_000
+)
+
+// AlphaBetaBot - Iterative-deepening negamax search with alpha-beta pruning over any game with an evaluator.
+type AlphaBetaBot struct {
+	evaluate Evaluator
+	settings searchSettings
+	rng      *rand.Rand
+}
+
+func NewAlphaBetaBot(evaluate Evaluator, difficulty Difficulty) *AlphaBetaBot {
+	return &AlphaBetaBot{
+		evaluate: evaluate,
+		settings: difficulty.searchSettings(),
+		rng:      rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// SelectTurn - Searches one ply deeper at a time until the depth limit or time limit is reached,
+// keeping the best turn from the deepest search that finished.
+func (bot *AlphaBetaBot) SelectTurn(g game.Game, playerNum int) (game.GameTurn, error) {
+	turns := g.GetValidTurns(playerNum)
+	if len(turns) == 0 {
+		return nil, ErrNoValidTurns
+	}
+	if len(turns) == 1 {
+		return turns[0], nil
+	}
+	if bot.rng.Float64() < bot.settings.mistakeRate {
+		return turns[bot.rng.IntN(len(turns))], nil
+	}
+
+	//shuffle so equally scored turns are not always picked in the same order
+	bot.rng.Shuffle(len(turns), func(i, j int) {
+		turns[i], turns[j] = turns[j], turns[i]
+	})
+
+	search := alphaBetaSearch{
+		evaluate: bot.evaluate,
+		deadline: time.Now().Add(bot.settings.timeLimit),
+	}
+
+	bestIdx := 0
+	for depth := 1; depth <= bot.settings.maxDepth; depth++ {
+		idx, score, ok := search.searchRoot(g, playerNum, turns, depth)
+		if !ok {
+			break
+		}
+		bestIdx = idx
+
+		//search the best turn first next iteration, it makes the cutoffs much more effective
+		turns[0], turns[bestIdx] = turns[bestIdx], turns[0]
+		bestIdx = 0
+
+		if score > winScore-forcedResultMargin || score < -winScore+forcedResultMargin {
+			break
+		}
+	}
+
+	return turns[bestIdx], nil
+}
+
+type alphaBetaSearch struct {
+	evaluate Evaluator
+	deadline time.Time
+	nodes    int
+}
+
+// searchRoot - Returns the index of the best turn and its score, or false if time ran out mid search.
+func (search *alphaBetaSearch) searchRoot(g game.Game, playerNum int, turns []game.GameTurn, depth int) (int, int, bool) {
+	bestIdx := 0
+	alpha, beta := -winScore-1, winScore+1
+	for i, turn := range turns {
+		child := g.Clone()
+		child.ExecuteTurn(turn, playerNum)
+		score, ok := search.negamax(child, otherPlayer(playerNum), depth-1, 1, -beta, -alpha)
+		if !ok {
+			return 0, 0, false
+		}
+		score = -score
+		if score > alpha {
+			alpha = score
+			bestIdx = i
+		}
+	}
+	return bestIdx, alpha, true
+}
+
+// negamax - Scores the position from the point of view of the player to move.
+func (search *alphaBetaSearch) negamax(g game.Game, playerNum int, depth int, ply int, alpha int, beta int) (int, bool) {
+	search.nodes++
+	if search.nodes%256 == 0 && time.Now().After(search.deadline) {
+		return 0, false
+	}
+
+	switch g.GetGameStatus() {
+	case game.GameStatusDraw:
+		return 0, true
+	case game.GameStatusPlayer1Win:
+		return terminalScore(1, playerNum, ply), true
+	case game.GameStatusPlayer2Win:
+		return terminalScore(2, playerNum, ply), true
+	}
+
+	if depth == 0 {
+		if search.evaluate == nil {
+			return 0, true
+		}
+		return search.evaluate(g, playerNum), true
+	}
+
+	turns := g.GetValidTurns(playerNum)
+	if len(turns) == 0 {
+		//a player who cannot move has lost
+		return -winScore + ply, true
+	}
+
+	best := -winScore - 1
+	for _, turn := range turns {
+		child := g.Clone()
+		child.ExecuteTurn(turn, playerNum)
+		score, ok := search.negamax(child, otherPlayer(playerNum), depth-1, ply+1, -beta, -alpha)
+		if !ok {
+			return 0, false
+		}
+		score = -score
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	return best, true
+}
+
+// terminalScore - Prefers quicker wins and slower losses by folding the ply into the score.
+func terminalScore(winner int, playerNum int, ply int) int {
+	if winner == playerNum {
+		return winScore - ply
+	}
+	return -winScore + ply
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func TestAlphaBetaTicTacToe(t *testing.T) {
+	tests := []struct {
+		name      string
+		board     [3][3]game.TicTacToeSquare
+		playerNum int
+		expected  vector.Vector
+	}{
+		{
+			name: "Takes the winning square",
+			board: [3][3]game.TicTacToeSquare{
+				{game.TicTacToeSquareX, game.TicTacToeSquareX, game.TicTacToeSquareEmpty},
+				{game.TicTacToeSquareO, game.TicTacToeSquareO, game.TicTacToeSquareEmpty},
+				{game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty},
+			},
+			playerNum: 1,
+			expected:  vector.NewVector(2, 0),
+		},
+		{
+			name: "Blocks the opponent's win",
+			board: [3][3]game.TicTacToeSquare{
+				{game.TicTacToeSquareX, game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty},
+				{game.TicTacToeSquareEmpty, game.TicTacToeSquareX, game.TicTacToeSquareEmpty},
+				{game.TicTacToeSquareO, game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty},
+			},
+			playerNum: 2,
+			expected:  vector.NewVector(2, 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticTacToe := game.NewTicTacToeGame()
+			ticTacToe.Board = tt.board
+
+			turn, err := NewBot(game.GameTypeTicTacToe, DifficultyHard).SelectTurn(ticTacToe, tt.playerNum)
+			if err != nil {
+				t.Fatalf("SelectTurn() returned error: %v", err)
+			}
+			coords := turn.(game.TicTacToeTurn).Coords
+			if !coords.Equals(tt.expected) {
+				t.Errorf("SelectTurn() = %v, expected %v", coords, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAlphaBetaTicTacToeSelfPlayIsDraw(t *testing.T) {
+	ticTacToe := game.NewTicTacToeGame()
+	bot := NewBot(game.GameTypeTicTacToe, DifficultyHard)
+
+	playerNum := 1
+	for ticTacToe.GetGameStatus() == game.GameStatusOngoing {
+		turn, err := bot.SelectTurn(ticTacToe, playerNum)
+		if err != nil {
+			t.Fatalf("SelectTurn() returned error: %v", err)
+		}
+		ticTacToe.ExecuteTurn(turn, playerNum)
+		playerNum = otherPlayer(playerNum)
+	}
+
+	if ticTacToe.GetGameStatus() != game.GameStatusDraw {
+		t.Errorf("perfect play should end in a draw, got status %v", ticTacToe.GetGameStatus())
+	}
+}
+
+func TestAlphaBetaCheckersCapture(t *testing.T) {
+	checkers := game.NewCheckersGame()
+	checkers.Board = [8][8]game.CheckersPiece{}
+	checkers.Board[4][4] = game.CheckersPiece{ID: 101, Color: "w"}
+	checkers.Board[3][3] = game.CheckersPiece{ID: 201, Color: "b"}
+	checkers.Board[0][6] = game.CheckersPiece{ID: 202, Color: "b"}
+
+	turn, err := NewBot(game.GameTypeCheckers, DifficultyHard).SelectTurn(checkers, 1)
+	if err != nil {
+		t.Fatalf("SelectTurn() returned error: %v", err)
+	}
+
+	expected := game.CheckersTurn{PieceCoords: vector.NewVector(4, 4), Direction: game.CheckersDirectionLeft}
+	if turn != expected {
+		t.Errorf("SelectTurn() = %+v, expected capture %+v", turn, expected)
+	}
+}
+
+func TestSelectTurnWithoutValidTurns(t *testing.T) {
+	ticTacToe := game.NewTicTacToeGame()
+	ticTacToe.OverrideGameStatus(game.GameStatusDraw)
+
+	_, err := NewBot(game.GameTypeTicTacToe, DifficultyEasy).SelectTurn(ticTacToe, 1)
+	if err != ErrNoValidTurns {
+		t.Errorf("SelectTurn() error = %v, expected %v", err, ErrNoValidTurns)
+	}
+}
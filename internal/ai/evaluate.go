@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+// Evaluator - Scores a non-terminal position from the given player's point of view. Higher is better.
+type Evaluator func(g game.Game, playerNum int) int
+
+func GetEvaluator(gameType game.GameType) Evaluator {
+	switch gameType {
+	case game.GameTypeTicTacToe:
+		return evaluateTicTacToe
+	case game.GameTypeCheckers:
+		return evaluateCheckers
+	default:
+		return nil
+	}
+}
+
+var ticTacToeLines = [8][3][2]int{
+	{{0, 0}, {0, 1}, {0, 2}},
+	{{1, 0}, {1, 1}, {1, 2}},
+	{{2, 0}, {2, 1}, {2, 2}},
+	{{0, 0}, {1, 0}, {2, 0}},
+	{{0, 1}, {1, 1}, {2, 1}},
+	{{0, 2}, {1, 2}, {2, 2}},
+	{{0, 0}, {1, 1}, {2, 2}},
+	{{0, 2}, {1, 1}, {2, 0}},
+}
+
+// evaluateTicTacToe - Rewards lines that only the player occupies, weighted by how full they are.
+func evaluateTicTacToe(g game.Game, playerNum int) int {
+	ticTacToe := g.(*game.TicTacToeGame)
+	playerSquare := game.TicTacToeSquareX
+	if playerNum == 2 {
+		playerSquare = game.TicTacToeSquareO
+	}
+
+	lineWeights := [3]int{0, 1, 10}
+	score := 0
+	for _, line := range ticTacToeLines {
+		mine, theirs := 0, 0
+		for _, square := range line {
+			switch ticTacToe.Board[square[0]][square[1]] {
+			case game.TicTacToeSquareEmpty:
+			case playerSquare:
+				mine++
+			default:
+				theirs++
+			}
+		}
+		if theirs == 0 && mine < 3 {
+			score += lineWeights[mine]
+		}
+		if mine == 0 && theirs < 3 {
+			score -= lineWeights[theirs]
+		}
+	}
+	return score
+}
+
+const (
+	checkersManValue      = 100
+	checkersKingValue     = 175
+	checkersAdvanceBonus  = 4
+	checkersCenterBonus   = 5
+	checkersBackRankBonus = 8
+)
+
+// evaluateCheckers - Material first, then advancement, center control and keeping the back rank guarded.
+func evaluateCheckers(g game.Game, playerNum int) int {
+	checkers := g.(*game.CheckersGame)
+
+	score := 0
+	for row := range checkers.Board {
+		for col := range checkers.Board[row] {
+			piece := checkers.Board[row][col]
+			if piece.Color == "" {
+				continue
+			}
+
+			owner := 1
+			if piece.BelongsTo(2) {
+				owner = 2
+			}
+
+			//rows advanced towards the opponent's side, from the owner's point of view
+			advanced := 7 - row
+			if owner == 2 {
+				advanced = row
+			}
+
+			value := 0
+			if piece.IsKing {
+				value += checkersKingValue
+			} else {
+				value += checkersManValue + advanced*checkersAdvanceBonus
+				if advanced == 0 {
+					value += checkersBackRankBonus
+				}
+			}
+			if row >= 2 && row <= 5 && col >= 2 && col <= 5 {
+				value += checkersCenterBonus
+			}
+
+			if owner == playerNum {
+				score += value
+			} else {
+				score -= value
+			}
+		}
+	}
+	return score
+}
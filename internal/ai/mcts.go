@@ -0,0 +1,249 @@
+package ai
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+const (
+	defaultExploration  = math.Sqrt2
+	defaultTimeBudget   = time.Second
+	maxPlayoutPlies     = 200
+	playoutDrawFraction = 0.5
+)
+
+// MCTSOptions - Playout budget for the search. When both Playouts and TimeBudget are set the
+// search stops at whichever runs out first, and when neither is set it runs for one second.
+type MCTSOptions struct {
+	Playouts    int
+	TimeBudget  time.Duration
+	Workers     int
+	Exploration float64
+}
+
+// MCTSBot - Monte Carlo tree search using UCT. It only relies on GetValidTurns, ExecuteTurn and
+// GetGameStatus, so it can play any game without a hand-written evaluator. Each worker grows its
+// own tree from the root (root parallelisation) and the root visit counts are summed at the end.
+type MCTSBot struct {
+	options MCTSOptions
+}
+
+func NewMCTSBot(options MCTSOptions) *MCTSBot {
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+	if options.Exploration <= 0 {
+		options.Exploration = defaultExploration
+	}
+	if options.Playouts <= 0 && options.TimeBudget <= 0 {
+		options.TimeBudget = defaultTimeBudget
+	}
+	return &MCTSBot{options: options}
+}
+
+func (bot *MCTSBot) SelectTurn(g game.Game, playerNum int) (game.GameTurn, error) {
+	turn, _, err := bot.search(g, playerNum)
+	return turn, err
+}
+
+// search - Runs the workers and returns the most visited root turn along with the number of playouts run.
+func (bot *MCTSBot) search(g game.Game, playerNum int) (game.GameTurn, int, error) {
+	turns := g.GetValidTurns(playerNum)
+	if len(turns) == 0 {
+		return nil, 0, ErrNoValidTurns
+	}
+	if len(turns) == 1 {
+		return turns[0], 0, nil
+	}
+
+	var deadline time.Time
+	if bot.options.TimeBudget > 0 {
+		deadline = time.Now().Add(bot.options.TimeBudget)
+	}
+
+	rootVisits := make([]int, len(turns))
+	playouts := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for worker := 0; worker < bot.options.Workers; worker++ {
+		workerPlayouts := 0
+		if bot.options.Playouts > 0 {
+			//spread the budget evenly, giving the remainder to the first workers
+			workerPlayouts = bot.options.Playouts / bot.options.Workers
+			if worker < bot.options.Playouts%bot.options.Workers {
+				workerPlayouts++
+			}
+			if workerPlayouts == 0 {
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tree := newMCTSTree(g, playerNum, bot.options.Exploration)
+			tree.run(workerPlayouts, deadline)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, child := range tree.root.children {
+				rootVisits[child.turnIdx] += child.visits
+			}
+			playouts += tree.root.visits
+		}()
+	}
+	wg.Wait()
+
+	bestIdx := 0
+	for i, visits := range rootVisits {
+		if visits > rootVisits[bestIdx] {
+			bestIdx = i
+		}
+	}
+	return turns[bestIdx], playouts, nil
+}
+
+type mctsNode struct {
+	parent   *mctsNode
+	children []*mctsNode
+	//turns not yet expanded into children, with their index in the parent's valid turns
+	untried    []game.GameTurn
+	untriedIdx []int
+
+	turn    game.GameTurn
+	turnIdx int
+	//mover is the player whose turn led here, toMove is the player to act next
+	mover  int
+	toMove int
+
+	visits int
+	//score is from the mover's point of view: a win counts 1, a draw counts half
+	score float64
+}
+
+func newMCTSNode(parent *mctsNode, g game.Game, turn game.GameTurn, turnIdx int, mover int) *mctsNode {
+	node := &mctsNode{
+		parent:  parent,
+		turn:    turn,
+		turnIdx: turnIdx,
+		mover:   mover,
+		toMove:  otherPlayer(mover),
+	}
+	if g.GetGameStatus() == game.GameStatusOngoing {
+		node.untried = g.GetValidTurns(node.toMove)
+		node.untriedIdx = make([]int, len(node.untried))
+		for i := range node.untriedIdx {
+			node.untriedIdx[i] = i
+		}
+	}
+	return node
+}
+
+type mctsTree struct {
+	root        *mctsNode
+	rootGame    game.Game
+	exploration float64
+	rng         *rand.Rand
+}
+
+func newMCTSTree(g game.Game, playerNum int, exploration float64) *mctsTree {
+	rootGame := g.Clone()
+	return &mctsTree{
+		//the root's mover is whoever moved last, so the player to move is playerNum
+		root:        newMCTSNode(nil, rootGame, nil, -1, otherPlayer(playerNum)),
+		rootGame:    rootGame,
+		exploration: exploration,
+		rng:         rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// run - Runs playouts until the playout count is reached or the deadline passes. A zero
+// value for either means there is no limit of that kind.
+func (tree *mctsTree) run(playouts int, deadline time.Time) {
+	for i := 0; playouts == 0 || i < playouts; i++ {
+		if !deadline.IsZero() && i%64 == 0 && time.Now().After(deadline) {
+			return
+		}
+		tree.iterate()
+	}
+}
+
+// iterate - One round of selection, expansion, random playout and backpropagation.
+func (tree *mctsTree) iterate() {
+	state := tree.rootGame.Clone()
+	node := tree.root
+
+	for len(node.untried) == 0 && len(node.children) > 0 {
+		node = tree.selectChild(node)
+		state.ExecuteTurn(node.turn, node.mover)
+	}
+
+	if len(node.untried) > 0 {
+		i := tree.rng.IntN(len(node.untried))
+		turn, turnIdx := node.untried[i], node.untriedIdx[i]
+		last := len(node.untried) - 1
+		node.untried[i], node.untriedIdx[i] = node.untried[last], node.untriedIdx[last]
+		node.untried, node.untriedIdx = node.untried[:last], node.untriedIdx[:last]
+
+		state.ExecuteTurn(turn, node.toMove)
+		child := newMCTSNode(node, state, turn, turnIdx, node.toMove)
+		node.children = append(node.children, child)
+		node = child
+	}
+
+	winner := tree.playout(state, node.toMove)
+	for ; node != nil; node = node.parent {
+		node.visits++
+		if winner == node.mover {
+			node.score++
+		} else if winner == 0 {
+			node.score += playoutDrawFraction
+		}
+	}
+}
+
+// selectChild - Picks the child with the highest upper confidence bound (UCB1).
+func (tree *mctsTree) selectChild(node *mctsNode) *mctsNode {
+	logVisits := math.Log(float64(node.visits))
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	for _, child := range node.children {
+		value := child.score/float64(child.visits) + tree.exploration*math.Sqrt(logVisits/float64(child.visits))
+		if value > bestValue {
+			best, bestValue = child, value
+		}
+	}
+	return best
+}
+
+// playout - Plays random turns to the end of the game and returns the winning player, or 0 for a draw.
+func (tree *mctsTree) playout(state game.Game, toMove int) int {
+	for ply := 0; ; ply++ {
+		switch state.GetGameStatus() {
+		case game.GameStatusPlayer1Win:
+			return 1
+		case game.GameStatusPlayer2Win:
+			return 2
+		case game.GameStatusDraw:
+			return 0
+		}
+
+		if ply == maxPlayoutPlies {
+			//long games are cut short and scored as a draw
+			return 0
+		}
+
+		turns := state.GetValidTurns(toMove)
+		if len(turns) == 0 {
+			//a player who cannot move has lost
+			return otherPlayer(toMove)
+		}
+		state.ExecuteTurn(turns[tree.rng.IntN(len(turns))], toMove)
+		toMove = otherPlayer(toMove)
+	}
+}
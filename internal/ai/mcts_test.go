@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func TestMCTSTakesWinningTicTacToeMove(t *testing.T) {
+	ticTacToe := game.NewTicTacToeGame()
+	ticTacToe.Board = [3][3]game.TicTacToeSquare{
+		{game.TicTacToeSquareX, game.TicTacToeSquareX, game.TicTacToeSquareEmpty},
+		{game.TicTacToeSquareO, game.TicTacToeSquareO, game.TicTacToeSquareEmpty},
+		{game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty, game.TicTacToeSquareEmpty},
+	}
+
+	bot := NewMCTSBot(MCTSOptions{Playouts: 4_000, Workers: 2})
+	turn, err := bot.SelectTurn(ticTacToe, 1)
+	if err != nil {
+		t.Fatalf("SelectTurn() returned error: %v", err)
+	}
+
+	expected := vector.NewVector(2, 0)
+	if coords := turn.(game.TicTacToeTurn).Coords; !coords.Equals(expected) {
+		t.Errorf("SelectTurn() = %v, expected %v", coords, expected)
+	}
+}
+
+func TestMCTSRespectsPlayoutBudget(t *testing.T) {
+	bot := NewMCTSBot(MCTSOptions{Playouts: 1_001, Workers: 4})
+	_, playouts, err := bot.search(game.NewCheckersGame(), 1)
+	if err != nil {
+		t.Fatalf("search() returned error: %v", err)
+	}
+	if playouts != 1_001 {
+		t.Errorf("search() ran %d playouts, expected 1001", playouts)
+	}
+}
+
+func TestMCTSSelectsValidCheckersTurn(t *testing.T) {
+	checkers := game.NewCheckersGame()
+	bot := NewMCTSBot(MCTSOptions{TimeBudget: 50 * time.Millisecond})
+
+	for _, playerNum := range []int{1, 2} {
+		turn, err := bot.SelectTurn(checkers, playerNum)
+		if err != nil {
+			t.Fatalf("SelectTurn() returned error: %v", err)
+		}
+		if ok, msg := checkers.ValidateMove(turn, playerNum); !ok {
+			t.Errorf("SelectTurn() for player %d returned invalid turn %+v: %s", playerNum, turn, msg)
+		}
+	}
+}
+
+func benchmarkMCTSPlayouts(b *testing.B, g game.Game) {
+	bot := NewMCTSBot(MCTSOptions{Playouts: 1_000})
+
+	playouts := 0
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		_, n, err := bot.search(g, 1)
+		if err != nil {
+			b.Fatalf("search() returned error: %v", err)
+		}
+		playouts += n
+	}
+	b.ReportMetric(float64(playouts)/time.Since(start).Seconds(), "playouts/s")
+}
+
+func BenchmarkMCTSTicTacToe(b *testing.B) {
+	benchmarkMCTSPlayouts(b, game.NewTicTacToeGame())
+}
+
+func BenchmarkMCTSCheckers(b *testing.B) {
+	benchmarkMCTSPlayouts(b, game.NewCheckersGame())
+}
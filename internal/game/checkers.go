@@ -120,6 +120,55 @@ func (game CheckersGame) SquareHasPlayerPiece(cursorPos vector.Vector, playerNum
 	return square.Color == playerColor
 }
 
+// BelongsTo - Reports whether the piece is controlled by the given player.
+func (piece CheckersPiece) BelongsTo(playerNum int) bool {
+	if playerNum == 1 {
+		return piece.Color == pieceWhite
+	}
+	return piece.Color == pieceBlack
+}
+
+var checkersAllDirections = [4]CheckersDirection{
+	CheckersDirectionLeft,
+	CheckersDirectionRight,
+	CheckersDirectionBackLeft,
+	CheckersDirectionBackRight,
+}
+
+// GetValidTurns - Returns every turn the player could legally take on the current board.
+func (game *CheckersGame) GetValidTurns(playerNum int) []GameTurn {
+	if game.GameStatus != GameStatusOngoing {
+		return nil
+	}
+
+	turns := []GameTurn{}
+	for row := range game.Board {
+		for col := range game.Board[row] {
+			piece := game.Board[row][col]
+			if !piece.BelongsTo(playerNum) {
+				continue
+			}
+
+			directions := checkersAllDirections[:]
+			if !piece.IsKing {
+				directions = directions[:2]
+			}
+			for _, direction := range directions {
+				turn := CheckersTurn{PieceCoords: vector.NewVector(col, row), Direction: direction}
+				if ok, _ := game.ValidateMove(turn, playerNum); ok {
+					turns = append(turns, turn)
+				}
+			}
+		}
+	}
+	return turns
+}
+
+func (game *CheckersGame) Clone() Game {
+	clone := *game
+	return &clone
+}
+
 // TODO switch this over to returning an error instead of bool + string
 func (game *CheckersGame) ValidateMove(gameTurn GameTurn, playerNum int) (bool, string) {
 	turn, ok := gameTurn.(CheckersTurn)
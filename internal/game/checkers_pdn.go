@@ -0,0 +1,254 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+// Draughts notation numbers the 32 playable squares 1 to 32, starting from black's side of the board
+// and reading each row left to right as white sees it. Black starts on 1-12 and white on 21-32, the
+// same as here, but this board is the standard one mirrored left to right, so columns are flipped on
+// the way in and out.
+
+var ErrInvalidNotation = errors.New("invalid checkers notation")
+
+// CheckersSquare - The coordinates of a square given its standard number.
+func CheckersSquare(number int) (vector.Vector, error) {
+	if number < 1 || number > 32 {
+		return vector.Vector{}, fmt.Errorf("%w: no square %d", ErrInvalidNotation, number)
+	}
+	row := (number - 1) / 4
+	standardCol := 2 * ((number - 1) % 4)
+	if row%2 == 0 {
+		standardCol++
+	}
+	return vector.NewVector(7-standardCol, row), nil
+}
+
+// CheckersSquareNumber - The standard number of the square at the coordinates, or 0 for a square
+// pieces never stand on.
+func CheckersSquareNumber(coords vector.Vector) int {
+	standardCol := 7 - coords.X
+	if coords.Y < 0 || coords.Y > 7 || standardCol < 0 || standardCol > 7 || (coords.Y+standardCol)%2 == 0 {
+		return 0
+	}
+	return coords.Y*4 + standardCol/2 + 1
+}
+
+// absoluteDirection - The direction of a one square diagonal step as white sees the board.
+func absoluteDirection(step vector.Vector) (CheckersDirection, bool) {
+	switch step {
+	case vector.NewVector(-1, -1):
+		return CheckersDirectionLeft, true
+	case vector.NewVector(1, -1):
+		return CheckersDirectionRight, true
+	case vector.NewVector(-1, 1):
+		return CheckersDirectionBackLeft, true
+	case vector.NewVector(1, 1):
+		return CheckersDirectionBackRight, true
+	}
+	return 0, false
+}
+
+// TurnNotation - Writes a turn the player is about to take as "11-15", or "11x18" for a capture.
+func (game *CheckersGame) TurnNotation(turn CheckersTurn, playerNum int) (string, error) {
+	if ok, msg := game.ValidateMove(turn, playerNum); !ok {
+		return "", fmt.Errorf("%w: %v", ErrInvalidNotation, msg)
+	}
+
+	trueDirection := turn.Direction
+	if playerNum == 2 {
+		trueDirection = convertDirectionFromBlackToWhite(trueDirection)
+	}
+	separator := "-"
+	target := applyMove(turn.PieceCoords, trueDirection)
+	if !game.isSquareEmpty(target) {
+		separator = "x"
+		target = applyMove(target, trueDirection)
+	}
+	return fmt.Sprintf("%d%s%d", CheckersSquareNumber(turn.PieceCoords), separator, CheckersSquareNumber(target)), nil
+}
+
+// ParseMove - Reads a move like "11-15", or a capture like "11x18x25", into the turns that make it
+// up. Each jump of a multiple capture is a turn of its own here, all by the same player. Live games
+// never jump twice in a row, so those turns are only good for replaying a game played elsewhere.
+func (game *CheckersGame) ParseMove(move string, playerNum int) ([]CheckersTurn, error) {
+	isCapture := strings.Contains(move, "x")
+	separator := "-"
+	if isCapture {
+		separator = "x"
+	}
+	parts := strings.Split(move, separator)
+	if len(parts) < 2 || (!isCapture && len(parts) != 2) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNotation, move)
+	}
+
+	squares := make([]vector.Vector, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidNotation, move)
+		}
+		if squares[i], err = CheckersSquare(number); err != nil {
+			return nil, err
+		}
+	}
+
+	distance := 1
+	if isCapture {
+		distance = 2
+	}
+
+	//jumps are played out on a copy, since each one starts where the last landed
+	board := *game
+	turns := []CheckersTurn{}
+	for i := 1; i < len(squares); i++ {
+		from, to := squares[i-1], squares[i]
+		step := vector.NewVector((to.X-from.X)/distance, (to.Y-from.Y)/distance)
+		direction, ok := absoluteDirection(step)
+		if !ok || to.X-from.X != step.X*distance || to.Y-from.Y != step.Y*distance {
+			return nil, fmt.Errorf("%w: cannot move from %v to %v in %q", ErrInvalidNotation, parts[i-1], parts[i], move)
+		}
+
+		//a capture must jump a piece and a plain move must not
+		if board.isSquareEmpty(applyMove(from, direction)) == isCapture {
+			return nil, fmt.Errorf("%w: %q does not match the board", ErrInvalidNotation, move)
+		}
+
+		if playerNum == 2 {
+			direction = convertDirectionFromBlackToWhite(direction)
+		}
+		turn := CheckersTurn{PieceCoords: from, Direction: direction}
+		if ok, msg := board.ValidateMove(turn, playerNum); !ok {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidNotation, move, msg)
+		}
+		board.ExecuteTurn(turn, playerNum)
+		turns = append(turns, turn)
+	}
+	return turns, nil
+}
+
+// FEN - Writes the position in PDN's FEN form, like "W:W21,22,K30:B1,2,3", with the player to move first.
+func (game *CheckersGame) FEN(playerToMove int) string {
+	var white, black []string
+	for number := 1; number <= 32; number++ {
+		square, _ := CheckersSquare(number)
+		piece := game.Board[square.Y][square.X]
+		entry := strconv.Itoa(number)
+		if piece.IsKing {
+			entry = "K" + entry
+		}
+		switch piece.Color {
+		case pieceWhite:
+			white = append(white, entry)
+		case pieceBlack:
+			black = append(black, entry)
+		}
+	}
+
+	toMove := "W"
+	if playerToMove == 2 {
+		toMove = "B"
+	}
+	return fmt.Sprintf("%v:W%v:B%v", toMove, strings.Join(white, ","), strings.Join(black, ","))
+}
+
+// ParseCheckersFEN - Sets up the position a FEN describes, and says whose move it is. Squares may be
+// listed one at a time or as ranges like "1-12".
+func ParseCheckersFEN(fen string) (*CheckersGame, int, error) {
+	fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(fen), "."), ":")
+	if len(fields) != 3 {
+		return nil, 0, fmt.Errorf("%w: FEN needs a side to move and both sides' pieces", ErrInvalidNotation)
+	}
+
+	playerToMove := 0
+	switch strings.ToUpper(fields[0]) {
+	case "W":
+		playerToMove = 1
+	case "B":
+		playerToMove = 2
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown side to move %q", ErrInvalidNotation, fields[0])
+	}
+
+	game := &CheckersGame{GameType: GameTypeCheckers, GameStatus: GameStatusOngoing}
+	for _, field := range fields[1:] {
+		if field == "" {
+			return nil, 0, fmt.Errorf("%w: empty FEN field", ErrInvalidNotation)
+		}
+		color, firstID := pieceWhite, 101
+		switch strings.ToUpper(field[:1]) {
+		case "W":
+		case "B":
+			color, firstID = pieceBlack, 201
+		default:
+			return nil, 0, fmt.Errorf("%w: unknown side %q", ErrInvalidNotation, field[:1])
+		}
+
+		pieces, err := parseFENSquares(field[1:])
+		if err != nil {
+			return nil, 0, err
+		}
+		//piece numbers on the board only go up to 12
+		if len(pieces) > 12 {
+			return nil, 0, fmt.Errorf("%w: more than 12 pieces for one side", ErrInvalidNotation)
+		}
+		//pieces are numbered in the same order a new game numbers them, top row first
+		id := firstID
+		for row := range game.Board {
+			for col := range game.Board[row] {
+				isKing, ok := pieces[CheckersSquareNumber(vector.NewVector(col, row))]
+				if !ok {
+					continue
+				}
+				if game.Board[row][col].Color != "" {
+					return nil, 0, fmt.Errorf("%w: square %d is taken twice", ErrInvalidNotation, CheckersSquareNumber(vector.NewVector(col, row)))
+				}
+				game.Board[row][col] = CheckersPiece{ID: id, Color: color, IsKing: isKing}
+				id++
+			}
+		}
+		if color == pieceWhite {
+			game.whitePieceCount = len(pieces)
+		} else {
+			game.blackPieceCount = len(pieces)
+		}
+	}
+	game.GameStatus = game.checkGameStatus()
+	return game, playerToMove, nil
+}
+
+// parseFENSquares - Reads one side's squares, mapped to whether the piece there is a king.
+func parseFENSquares(list string) (map[int]bool, error) {
+	pieces := map[int]bool{}
+	if list == "" {
+		return pieces, nil
+	}
+	for _, entry := range strings.Split(list, ",") {
+		isKing := strings.HasPrefix(strings.ToUpper(entry), "K")
+		if isKing {
+			entry = entry[1:]
+		}
+
+		first, last, isRange := strings.Cut(entry, "-")
+		if !isRange {
+			last = first
+		}
+		from, errFrom := strconv.Atoi(first)
+		to, errTo := strconv.Atoi(last)
+		if errFrom != nil || errTo != nil || from > to {
+			return nil, fmt.Errorf("%w: bad FEN square %q", ErrInvalidNotation, entry)
+		}
+		for number := from; number <= to; number++ {
+			if _, err := CheckersSquare(number); err != nil {
+				return nil, err
+			}
+			pieces[number] = isKing
+		}
+	}
+	return pieces, nil
+}
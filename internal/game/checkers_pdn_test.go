@@ -0,0 +1,145 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func TestCheckersSquareNumbering(t *testing.T) {
+	tests := []struct {
+		number int
+		coords vector.Vector
+	}{
+		{number: 1, coords: vector.NewVector(6, 0)},
+		{number: 4, coords: vector.NewVector(0, 0)},
+		{number: 5, coords: vector.NewVector(7, 1)},
+		{number: 24, coords: vector.NewVector(1, 5)},
+		{number: 32, coords: vector.NewVector(1, 7)},
+	}
+
+	for _, test := range tests {
+		coords, err := CheckersSquare(test.number)
+		if err != nil || coords != test.coords {
+			t.Errorf("square %d: expected %v, got %v (%v)", test.number, test.coords, coords, err)
+		}
+		if number := CheckersSquareNumber(test.coords); number != test.number {
+			t.Errorf("coords %v: expected square %d, got %d", test.coords, test.number, number)
+		}
+	}
+
+	//every numbered square must hold a piece at the start, and there are 32 of them
+	game := NewCheckersGame()
+	for number := 1; number <= 32; number++ {
+		coords, _ := CheckersSquare(number)
+		hasPiece := game.Board[coords.Y][coords.X].Color != ""
+		if hasPiece != (number <= 12 || number >= 21) {
+			t.Errorf("square %d at %v has the wrong starting piece", number, coords)
+		}
+	}
+
+	if _, err := CheckersSquare(33); !errors.Is(err, ErrInvalidNotation) {
+		t.Errorf("expected square 33 to be rejected, got %v", err)
+	}
+	if number := CheckersSquareNumber(vector.NewVector(0, 1)); number != 0 {
+		t.Errorf("expected a light square to have no number, got %d", number)
+	}
+}
+
+func TestCheckersMoveNotation(t *testing.T) {
+	game := NewCheckersGame()
+
+	turns, err := game.ParseMove("24-19", 1)
+	if err != nil {
+		t.Fatalf("expected 24-19 to parse, got %v", err)
+	}
+	expected := CheckersTurn{PieceCoords: vector.NewVector(1, 5), Direction: CheckersDirectionRight}
+	if len(turns) != 1 || turns[0] != expected {
+		t.Fatalf("expected %v, got %v", expected, turns)
+	}
+	if notation, err := game.TurnNotation(turns[0], 1); err != nil || notation != "24-19" {
+		t.Errorf("expected 24-19 to be written back, got %q (%v)", notation, err)
+	}
+
+	//black's directions are relative to black, so moving down the board is forwards
+	turns, err = game.ParseMove("9-14", 2)
+	if err != nil || len(turns) != 1 || turns[0].Direction == CheckersDirectionBackLeft || turns[0].Direction == CheckersDirectionBackRight {
+		t.Fatalf("expected 9-14 to be a forward move for black, got %v (%v)", turns, err)
+	}
+	game.ExecuteTurn(turns[0], 2)
+	if turns, err = game.ParseMove("19-14", 1); err == nil {
+		t.Errorf("expected a move from an empty square to fail, got %v", turns)
+	}
+
+	game.ExecuteTurn(CheckersTurn{PieceCoords: vector.NewVector(1, 5), Direction: CheckersDirectionRight}, 1)
+	for _, move := range []string{"14-23", "14x23", "10x17", "14"} {
+		if _, err := game.ParseMove(move, 2); !errors.Is(err, ErrInvalidNotation) {
+			t.Errorf("expected %q to be rejected, got %v", move, err)
+		}
+	}
+	turns, err = game.ParseMove("14-18", 2)
+	if err != nil {
+		t.Fatalf("expected 14-18 to parse, got %v", err)
+	}
+	game.ExecuteTurn(turns[0], 2)
+
+	turns, err = game.ParseMove("23x14", 1)
+	if err != nil || len(turns) != 1 {
+		t.Fatalf("expected the capture 23x14 to parse, got %v (%v)", turns, err)
+	}
+	if notation, _ := game.TurnNotation(turns[0], 1); notation != "23x14" {
+		t.Errorf("expected the capture to be written as 23x14, got %q", notation)
+	}
+}
+
+func TestCheckersMultipleCapture(t *testing.T) {
+	game, playerToMove, err := ParseCheckersFEN("W:W27:B23,14")
+	if err != nil || playerToMove != 1 {
+		t.Fatalf("expected the position to parse with white to move, got %v (%v)", playerToMove, err)
+	}
+
+	turns, err := game.ParseMove("27x18x9", 1)
+	if err != nil || len(turns) != 2 {
+		t.Fatalf("expected a double jump, got %v (%v)", turns, err)
+	}
+	for _, turn := range turns {
+		game.ExecuteTurn(turn, 1)
+	}
+	if status := game.GetGameStatus(); status != GameStatusPlayer1Win {
+		t.Errorf("expected white to win after taking both pieces, got %v", status)
+	}
+}
+
+func TestCheckersFEN(t *testing.T) {
+	start := NewCheckersGame()
+	if fen := start.FEN(1); fen != "W:W21,22,23,24,25,26,27,28,29,30,31,32:B1,2,3,4,5,6,7,8,9,10,11,12" {
+		t.Errorf("unexpected FEN for the starting position: %v", fen)
+	}
+
+	game, playerToMove, err := ParseCheckersFEN("W:W21-32:B1-12")
+	if err != nil || playerToMove != 1 {
+		t.Fatalf("expected the starting position to parse, got %v (%v)", playerToMove, err)
+	}
+	if game.Board != start.Board {
+		t.Error("expected ranges to set up the starting position")
+	}
+
+	game, playerToMove, err = ParseCheckersFEN("B:WK3,20:B12.")
+	if err != nil || playerToMove != 2 {
+		t.Fatalf("expected black to move, got %v (%v)", playerToMove, err)
+	}
+	king, _ := CheckersSquare(3)
+	if piece := game.Board[king.Y][king.X]; !piece.IsKing || piece.Color != pieceWhite {
+		t.Errorf("expected a white king on 3, got %+v", piece)
+	}
+	if fen := game.FEN(playerToMove); fen != "B:WK3,20:B12" {
+		t.Errorf("expected the position to be written back, got %v", fen)
+	}
+
+	for _, fen := range []string{"", "X:W1:B2", "W:W1:B1", "W:W33:B2", "W:W1-13:B14"} {
+		if _, _, err := ParseCheckersFEN(fen); !errors.Is(err, ErrInvalidNotation) {
+			t.Errorf("expected %q to be rejected, got %v", fen, err)
+		}
+	}
+}
@@ -130,3 +130,70 @@ func TestExecuteTurnWithCapture(t *testing.T) {
 		t.Error("White piece should move to position after captured piece")
 	}
 }
+
+// fenGame - Sets up a position from a FEN, so a test can name its board in one line.
+func fenGame(t *testing.T, fen string) *CheckersGame {
+	t.Helper()
+	game, _, err := ParseCheckersFEN(fen)
+	if err != nil {
+		t.Fatalf("ParseCheckersFEN(%q) failed: %v", fen, err)
+	}
+	return game
+}
+
+// fenTurn - White's turn moving the piece on the numbered square.
+func fenTurn(t *testing.T, square int, direction CheckersDirection) CheckersTurn {
+	t.Helper()
+	coords, err := CheckersSquare(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CheckersTurn{PieceCoords: coords, Direction: direction}
+}
+
+func TestCheckersRulesFromFEN(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		square      int
+		direction   CheckersDirection
+		expectedOK  bool
+		expectedMsg string
+	}{
+		{"King moves backwards", "W:WK18:B1", 18, CheckersDirectionBackLeft, true, ""},
+		{"Man cannot move backwards", "W:W18:B1", 18, CheckersDirectionBackLeft, false, "only kings can move backwards"},
+		{"Jump lands on a piece", "W:W18:B14,9", 18, CheckersDirectionRight, false, "destination is occupied"},
+		{"Jump lands off the board", "W:W5:B1", 5, CheckersDirectionLeft, false, "destination is out of bounds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := fenGame(t, tt.fen)
+			ok, msg := game.ValidateMove(fenTurn(t, tt.square, tt.direction), 1)
+			if ok != tt.expectedOK || msg != tt.expectedMsg {
+				t.Errorf("ValidateMove() = %v, %q, want %v, %q", ok, msg, tt.expectedOK, tt.expectedMsg)
+			}
+		})
+	}
+}
+
+func TestCheckersPromotionFromFEN(t *testing.T) {
+	game := fenGame(t, "W:W6:B12")
+	game.ExecuteTurn(fenTurn(t, 6, CheckersDirectionLeft), 1)
+
+	if fen := game.FEN(2); fen != "B:WK2:B12" {
+		t.Errorf("FEN after reaching the last row = %q, want %q", fen, "B:WK2:B12")
+	}
+}
+
+func TestCheckersLastCaptureWinsFromFEN(t *testing.T) {
+	game := fenGame(t, "W:W18:B14")
+	game.ExecuteTurn(fenTurn(t, 18, CheckersDirectionRight), 1)
+
+	if fen := game.FEN(2); fen != "B:W9:B" {
+		t.Errorf("FEN after the capture = %q, want %q", fen, "B:W9:B")
+	}
+	if game.GetGameStatus() != GameStatusPlayer1Win {
+		t.Errorf("Game status = %v, want a white win", game.GetGameStatus())
+	}
+}
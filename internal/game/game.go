@@ -41,6 +41,8 @@ type Game interface {
 	ValidateMove(GameTurn, int) (bool, string)
 	ExecuteTurn(GameTurn, int) string
 	DisplayBoard(vector.Vector, int) string
+	GetValidTurns(int) []GameTurn
+	Clone() Game
 }
 
 type GameTurn interface {
@@ -66,6 +66,28 @@ func (game *TicTacToeGame) ValidateMove(gameTurn GameTurn, playerNum int) (bool,
 	return true, ""
 }
 
+// GetValidTurns - Returns every turn the player could legally take on the current board.
+func (game *TicTacToeGame) GetValidTurns(playerNum int) []GameTurn {
+	if game.GameStatus != GameStatusOngoing {
+		return nil
+	}
+
+	turns := []GameTurn{}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			if game.Board[row][col] == TicTacToeSquareEmpty {
+				turns = append(turns, TicTacToeTurn{Coords: vector.NewVector(col, row)})
+			}
+		}
+	}
+	return turns
+}
+
+func (game *TicTacToeGame) Clone() Game {
+	clone := *game
+	return &clone
+}
+
 type TicTacToeTurn struct {
 	Coords vector.Vector `json:"coords"`
 }
@@ -0,0 +1,100 @@
+package gameroom
+
+import (
+	"log"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+// The clocks follow the same pattern as the reconnect grace period: the room goroutine owns a
+// timer, and Run selects on it so a player who runs out of time loses even if nobody sends anything.
+
+// startClocks - Sets both clocks for a new game and starts the one of the player to move.
+func (room *Room) startClocks() {
+	room.stopClock()
+	room.timedOutPlayerNum = 0
+	room.clocks = [2]time.Duration{}
+	if !room.options.TimeControl.IsTimed() {
+		return
+	}
+
+	start := room.options.TimeControl.StartingClock()
+	room.clocks = [2]time.Duration{start, start}
+	room.turnStarted = time.Now()
+	room.resetClockTimer()
+}
+
+// clocksNow - Each player's time left right now, taking off what the player to move has used so far.
+func (room *Room) clocksNow() [2]time.Duration {
+	clocks := room.clocks
+	if room.clockTimer != nil {
+		clocks[room.playerTurn-1] = max(clocks[room.playerTurn-1]-time.Since(room.turnStarted), 0)
+	}
+	return clocks
+}
+
+// chargeClock - Takes the time a move took off the mover's clock and adds their increment, or tops
+// them back up to the time per move. Returns false if they ran out before moving.
+func (room *Room) chargeClock(playerNumber int) bool {
+	if room.clockTimer == nil {
+		return true
+	}
+
+	now := time.Now()
+	left := room.clocks[playerNumber-1] - now.Sub(room.turnStarted)
+	if left <= 0 {
+		return false
+	}
+	control := room.options.TimeControl
+	if control.PerMove > 0 {
+		left = control.PerMove
+	} else {
+		left += control.Increment
+	}
+	room.clocks[playerNumber-1] = left
+	room.turnStarted = now
+	return true
+}
+
+// resetClockTimer - Arms the timer for the player to move's time left.
+func (room *Room) resetClockTimer() {
+	if room.clockTimer != nil {
+		room.clockTimer.Stop()
+	}
+	room.clockTimer = time.NewTimer(room.clocks[room.playerTurn-1])
+}
+
+// stopClock - Freezes both clocks as they are, once the game is over.
+func (room *Room) stopClock() {
+	if room.clockTimer == nil {
+		return
+	}
+	room.clocks = room.clocksNow()
+	room.clockTimer.Stop()
+	room.clockTimer = nil
+}
+
+func (room *Room) clockExpired() <-chan time.Time {
+	if room.clockTimer == nil {
+		return nil
+	}
+	return room.clockTimer.C
+}
+
+// timeOut - The player to move ran out of time, and loses.
+func (room *Room) timeOut() {
+	loser := room.playerTurn
+	log.Printf("Room %v player %v ran out of time", room.code, loser)
+	room.stopClock()
+	room.clocks[loser-1] = 0
+	room.timedOutPlayerNum = loser
+	room.record.TimedOutPlayerNum = loser
+
+	if loser == 1 {
+		room.game.OverrideGameStatus(game.GameStatusPlayer2Win)
+	} else {
+		room.game.OverrideGameStatus(game.GameStatusPlayer1Win)
+	}
+	room.endGameOnCompletion()
+}
@@ -0,0 +1,53 @@
+package gameroom
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	CodeLength = 5
+	//no 0/O, 1/I/L or similar pairs, so a code read aloud or off a screen is typed back correctly
+	codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrMalformedCode = errors.New("malformed room code")
+	ErrRoomNotFound  = errors.New("no room with that code")
+	ErrRoomClosing   = errors.New("room is closing, try again in a moment")
+	ErrWrongPassword = errors.New("wrong password for that room")
+)
+
+// ValidateCode - Checks that a code could have come from NewCode, so a typo is caught before the
+// hub goes looking for the room.
+func ValidateCode(code string) error {
+	if len(code) != CodeLength {
+		return fmt.Errorf("%w: %q should be %d characters", ErrMalformedCode, code, CodeLength)
+	}
+	for _, char := range code {
+		if !strings.ContainsRune(codeAlphabet, char) {
+			return fmt.Errorf("%w: %q contains %q", ErrMalformedCode, code, char)
+		}
+	}
+	return nil
+}
+
+// NewCode - Generates an unguessable room code that is not already taken.
+func NewCode(taken func(code string) bool) string {
+	for {
+		code := make([]byte, CodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+			if err != nil {
+				panic(err)
+			}
+			code[i] = codeAlphabet[n.Int64()]
+		}
+		if !taken(string(code)) {
+			return string(code)
+		}
+	}
+}
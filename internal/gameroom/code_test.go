@@ -0,0 +1,57 @@
+package gameroom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewCodeUsesUnambiguousAlphabet(t *testing.T) {
+	for range 100 {
+		code := NewCode(func(string) bool { return false })
+		if len(code) != CodeLength {
+			t.Fatalf("expected a %d character code, got %q", CodeLength, code)
+		}
+		for _, char := range code {
+			if !strings.ContainsRune(codeAlphabet, char) {
+				t.Fatalf("code %q contains %q, which is not in the alphabet", code, char)
+			}
+		}
+	}
+}
+
+func TestNewCodeSkipsTakenCodes(t *testing.T) {
+	attempts := 0
+	NewCode(func(string) bool {
+		attempts++
+		return attempts < 3
+	})
+	if attempts != 3 {
+		t.Errorf("expected a fresh code on the third attempt, took %d", attempts)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{NewCode(func(string) bool { return false }), true},
+		{"ABC23", true},
+		{"", false},
+		{"ABC2", false},
+		{"ABC234", false},
+		{"abc23", false},
+		{"ABC0O", false},
+		{"AB-23", false},
+	}
+	for _, test := range tests {
+		err := ValidateCode(test.code)
+		if test.valid && err != nil {
+			t.Errorf("expected %q to be valid, got %v", test.code, err)
+		}
+		if !test.valid && !errors.Is(err, ErrMalformedCode) {
+			t.Errorf("expected %q to be rejected as malformed, got %v", test.code, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package gameroom
+
+import (
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	//a player may send emoteBurst emotes in any emoteWindow, and the rest are dropped
+	emoteBurst  = 3
+	emoteWindow = 10 * time.Second
+)
+
+// rateLimiter - Lets a player send burst messages of one kind in any window, remembering when the
+// latest were sent, oldest first.
+type rateLimiter struct {
+	burst  int
+	window time.Duration
+	sent   []time.Time
+}
+
+func newRateLimiter(burst int, window time.Duration) rateLimiter {
+	return rateLimiter{burst: burst, window: window}
+}
+
+// allow - Reports whether another message fits in the window, and counts it if so.
+func (limiter *rateLimiter) allow(now time.Time) bool {
+	for len(limiter.sent) > 0 && now.Sub(limiter.sent[0]) >= limiter.window {
+		limiter.sent = limiter.sent[1:]
+	}
+	if len(limiter.sent) >= limiter.burst {
+		return false
+	}
+	limiter.sent = append(limiter.sent, now)
+	return true
+}
+
+// relayEmote - Passes a player's emote on to both players, so the sender sees it went out. Emotes
+// past the rate limit are dropped without a word, since there is nothing for the sender to fix.
+func (room *Room) relayEmote(emote messages.Emote, playerNumber int) {
+	if !emote.IsValid() || !room.emoteLimiters[playerNumber-1].allow(time.Now()) {
+		return
+	}
+	msg := messages.ServerMessage{
+		Type:         messages.ServerEmote,
+		PlayerNumber: playerNumber,
+		Emote:        emote,
+	}
+	room.sendTo(1, msg)
+	room.sendTo(2, msg)
+}
@@ -0,0 +1,41 @@
+package gameroom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxNicknameLength = 16
+
+var ErrInvalidNickname = errors.New("invalid nickname")
+
+// NormalizeNickname - Trims a nickname and checks it will draw cleanly in the other player's
+// terminal. An empty nickname is fine, the room names the seat instead.
+func NormalizeNickname(nickname string) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if utf8.RuneCountInString(nickname) > MaxNicknameLength {
+		return "", fmt.Errorf("%w: at most %d characters", ErrInvalidNickname, MaxNicknameLength)
+	}
+	for _, char := range nickname {
+		//control characters could carry escape sequences into the other player's terminal
+		if !unicode.IsPrint(char) {
+			return "", fmt.Errorf("%w: %q is not allowed", ErrInvalidNickname, char)
+		}
+	}
+	return nickname, nil
+}
+
+// uniqueNickname - Names a seat so that the two players can always be told apart.
+func uniqueNickname(nickname string, playerNumber int, otherNickname string) string {
+	if nickname == "" {
+		nickname = fmt.Sprintf("Player %d", playerNumber)
+	}
+	unique := nickname
+	for suffix := 2; strings.EqualFold(unique, otherNickname); suffix++ {
+		unique = fmt.Sprintf("%v (%d)", nickname, suffix)
+	}
+	return unique
+}
@@ -0,0 +1,53 @@
+package gameroom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNickname(t *testing.T) {
+	tests := []struct {
+		nickname string
+		expected string
+		valid    bool
+	}{
+		{"  Ada  ", "Ada", true},
+		{"", "", true},
+		{"Grace Hopper", "Grace Hopper", true},
+		{"Zoë", "Zoë", true},
+		{strings.Repeat("a", MaxNicknameLength), strings.Repeat("a", MaxNicknameLength), true},
+		{strings.Repeat("a", MaxNicknameLength+1), "", false},
+		{"\x1b[31mred", "", false},
+		{"tab\there", "", false},
+	}
+	for _, test := range tests {
+		nickname, err := NormalizeNickname(test.nickname)
+		if test.valid && (err != nil || nickname != test.expected) {
+			t.Errorf("expected %q to normalize to %q, got %q and %v", test.nickname, test.expected, nickname, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidNickname) {
+			t.Errorf("expected %q to be rejected, got %v", test.nickname, err)
+		}
+	}
+}
+
+func TestUniqueNickname(t *testing.T) {
+	tests := []struct {
+		nickname      string
+		otherNickname string
+		expected      string
+	}{
+		{"Ada", "", "Ada"},
+		{"Ada", "Grace", "Ada"},
+		{"Ada", "ada", "Ada (2)"},
+		{"Ada", "Ada (2)", "Ada"},
+		{"", "Grace", "Player 2"},
+		{"", "Player 2", "Player 2 (2)"},
+	}
+	for _, test := range tests {
+		if unique := uniqueNickname(test.nickname, 2, test.otherNickname); unique != test.expected {
+			t.Errorf("expected %q next to %q to become %q, got %q", test.nickname, test.otherNickname, test.expected, unique)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package gameroom
+
+import (
+	"log"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+// makeOffer - Passes a draw offer or takeback request on to the opponent, who answers it with
+// ClientAnswerOffer. A player has at most one offer of each kind waiting at a time.
+func (room *Room) makeOffer(offer messages.Offer, playerNumber int) {
+	refuse := func(reason string) {
+		room.sendTo(playerNumber, messages.ServerMessage{Type: messages.ServerError, ErrorMessage: reason})
+	}
+	switch {
+	case offer != messages.OfferDraw && offer != messages.OfferTakeback:
+		refuse("Unknown offer.")
+		return
+	case room.offers[offer] == playerNumber:
+		refuse("You are still waiting for an answer.")
+		return
+	case room.offers[offer] != 0:
+		refuse("Answer your opponent's " + offer.String() + " offer first.")
+		return
+	case offer == messages.OfferTakeback && room.takebackFrom(playerNumber) < 0:
+		refuse("You have no move to take back.")
+		return
+	}
+
+	room.offers[offer] = playerNumber
+	room.sendTo(otherPlayer(playerNumber), messages.ServerMessage{
+		Type:         messages.ServerOffer,
+		PlayerNumber: playerNumber,
+		Offer:        offer,
+	})
+}
+
+// answerOffer - Settles the opponent's offer. Answers to an offer that was never made, or that a
+// move has since withdrawn, are dropped since they cross paths with the move.
+func (room *Room) answerOffer(offer messages.Offer, accept bool, playerNumber int) {
+	offeredBy := otherPlayer(playerNumber)
+	if offer != messages.OfferDraw && offer != messages.OfferTakeback || room.offers[offer] != offeredBy {
+		return
+	}
+	room.offers[offer] = 0
+
+	answer := messages.ServerMessage{
+		Type:         messages.ServerOfferAnswered,
+		PlayerNumber: playerNumber,
+		Offer:        offer,
+		Accepted:     accept,
+	}
+	room.sendTo(1, answer)
+	room.sendTo(2, answer)
+	if !accept {
+		return
+	}
+
+	log.Printf("Room %v player %v accepted a %v offer", room.code, playerNumber, offer)
+	switch offer {
+	case messages.OfferDraw:
+		room.game.OverrideGameStatus(game.GameStatusDraw)
+		room.endGameOnCompletion()
+	case messages.OfferTakeback:
+		room.takeBack(offeredBy)
+	}
+}
+
+// withdrawOffers - A move changes the position any waiting offer was made in, so it no longer stands.
+func (room *Room) withdrawOffers() {
+	room.offers = [2]int{}
+}
+
+// takebackFrom - The index in the record of the player's last turn, or -1 if they have not moved.
+// Anything the opponent played since is undone with it.
+func (room *Room) takebackFrom(playerNumber int) int {
+	for i := len(room.record.Turns) - 1; i >= 0; i-- {
+		if room.record.Turns[i].PlayerNumber == playerNumber {
+			return i
+		}
+	}
+	return -1
+}
+
+// takeBack - Rewinds the game to just before the player's last turn, rebuilding the position from
+// the record, and gives them the move again.
+func (room *Room) takeBack(playerNumber int) {
+	from := room.takebackFrom(playerNumber)
+	if from < 0 {
+		return
+	}
+	positions, err := room.record.Positions()
+	if err != nil || from >= len(positions) {
+		log.Printf("Room %v could not take back a move: %v", room.code, err)
+		return
+	}
+
+	//the clock that was running is charged for the time used so far, but gets no increment
+	if room.clockTimer != nil {
+		room.clocks = room.clocksNow()
+		room.turnStarted = time.Now()
+	}
+	room.game = positions[from]
+	room.record.Turns = room.record.Turns[:from]
+	room.playerTurn = playerNumber
+	room.withdrawOffers()
+	if room.clockTimer != nil {
+		room.resetClockTimer()
+	}
+
+	turnResult := messages.ServerMessage{
+		Type:       messages.ServerTurnResult,
+		Game:       messages.NewGameWrapper(room.game),
+		PlayerTurn: room.playerTurn,
+	}
+	room.sendTo(1, turnResult)
+	room.sendTo(2, turnResult)
+	room.sendToSpectators(turnResult)
+}
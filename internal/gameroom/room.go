@@ -0,0 +1,962 @@
+package gameroom
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+// Request - Asks a room to seat the player that owns the channels. A request with a resume token
+// asks for the seat that token was issued for, instead of a new one, and a leave request takes a
+// spectator back out of the room.
+type Request struct {
+	Code        string
+	Chans       Chans
+	ResumeToken string
+	Leave       bool
+	Public      bool
+	Password    string
+	Nickname    string
+	//Account is the logged in player's username, empty for guests
+	Account string
+	//Create asks the hub for a new room under a fresh code, Code is ignored
+	Create bool
+	//Rated and TimeControl only count when creating a room
+	Rated       bool
+	TimeControl messages.TimeControl
+}
+
+// Chans - The pair of channels a room and a seated player talk over.
+type Chans struct {
+	RoomToPlayer chan messages.ServerMessage
+	PlayerToRoom chan messages.ClientMessage
+}
+
+// Options - Room settings that the server can tune.
+type Options struct {
+	//how long a dropped player's seat is held for them, zero means a drop counts as quitting
+	ReconnectGrace time.Duration
+	//public rooms are listed in the lobby
+	Public bool
+	//quick match rooms skip game selection and start GameType as soon as both seats fill
+	AutoStart bool
+	GameType  game.GameType
+	//a checkers FEN to start checkers games from instead of the usual position
+	Setup string
+	//joiners must give the password, empty means anyone with the code can join
+	Password string
+	//rated rooms move the players' ratings as well as their win and loss counts
+	Rated bool
+	//TimeControl puts clocks on every game in the room, the zero value leaves them untimed
+	TimeControl messages.TimeControl
+	//how long a game in progress may carry on once the server starts shutting down
+	DrainWindow time.Duration
+	//OnResult is told about every decided game, so the server can keep score
+	OnResult func(Result)
+}
+
+// Result - How a game ended and who played it.
+type Result struct {
+	GameType game.GameType
+	//per seat, empty for guests
+	Accounts [2]string
+	Results  [2]messages.GameResult
+	Rated    bool
+	//Record is the whole game, turn by turn, ready to be kept
+	Record messages.GameRecord
+}
+
+type Room struct {
+	code    string
+	options Options
+
+	gameType   game.GameType
+	game       game.Game
+	playerTurn int
+	//record grows by a turn each time one is accepted, and is handed to OnResult once the game is decided
+	record messages.GameRecord
+
+	//clocks are each player's time left as of turnStarted, and the timer runs only during a timed game
+	clocks            [2]time.Duration
+	turnStarted       time.Time
+	clockTimer        *time.Timer
+	timedOutPlayerNum int
+	//offers holds who made each waiting offer, indexed by messages.Offer, 0 when none is waiting
+	offers [2]int
+	//per seat, indexed by player number - 1
+	emoteLimiters [2]rateLimiter
+	chatLimiters  [2]rateLimiter
+	//draining is set once the server is shutting down, and drainTimer ends any game still going
+	draining   bool
+	drainTimer *time.Timer
+
+	waitingForPlayerOne RoomStateWaitingForP1
+	waitingForPlayerTwo RoomStateWaitingForP2
+	inGameSelection     RoomStateInGameSelection
+	running             RoomStateRunning
+	postGame            RoomStatePostGame
+	state               RoomState
+
+	playerOneChans Chans
+	playerTwoChans Chans
+	//per seat, indexed by player number - 1
+	nicknames      [2]string
+	accounts       [2]string
+	resumeTokens   [2]string
+	graceDeadlines [2]time.Time
+	graceTimer     *time.Timer
+
+	spectators []Chans
+
+	//info is the lobby's view of the room, written by the room goroutine and read by the hub
+	infoMu sync.Mutex
+	info   messages.RoomInfo
+
+	requests chan Request
+	closeReq chan string
+	done     chan struct{}
+}
+
+func NewRoom(code string, closeReq chan string, options Options) *Room {
+	room := &Room{
+		code:    code,
+		options: options,
+	}
+	room.waitingForPlayerOne = RoomStateWaitingForP1{room}
+	room.waitingForPlayerTwo = RoomStateWaitingForP2{room}
+	room.inGameSelection = RoomStateInGameSelection{room}
+	room.running = RoomStateRunning{room}
+	room.postGame = RoomStatePostGame{room, &rematchVotes{}}
+	room.state = &room.waitingForPlayerOne
+	for i := range 2 {
+		room.emoteLimiters[i] = newRateLimiter(emoteBurst, emoteWindow)
+		room.chatLimiters[i] = newRateLimiter(chatBurst, chatWindow)
+	}
+	room.requests = make(chan Request)
+	room.closeReq = closeReq
+	room.done = make(chan struct{})
+	room.updateInfo()
+	return room
+}
+
+func (room *Room) Code() string {
+	return room.code
+}
+
+func (room *Room) IsPublic() bool {
+	return room.options.Public
+}
+
+// CheckPassword - Options never change once the room is made, so this is safe from any goroutine.
+func (room *Room) CheckPassword(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(password), []byte(room.options.Password)) == 1
+}
+
+// Info - The latest lobby snapshot of the room. Safe to call from any goroutine.
+func (room *Room) Info() messages.RoomInfo {
+	room.infoMu.Lock()
+	defer room.infoMu.Unlock()
+	return room.info
+}
+
+// updateInfo - Refreshes the lobby snapshot. Called by the room goroutine after every event.
+func (room *Room) updateInfo() {
+	players := 0
+	for i, chans := range []Chans{room.playerOneChans, room.playerTwoChans} {
+		//a held seat still belongs to its player
+		if chans != (Chans{}) || !room.graceDeadlines[i].IsZero() {
+			players++
+		}
+	}
+
+	info := messages.RoomInfo{
+		Code:        room.code,
+		GameType:    room.gameType,
+		Players:     players,
+		Spectators:  len(room.spectators),
+		Phase:       room.state.phase(),
+		Locked:      room.options.Password != "",
+		Rated:       room.options.Rated,
+		TimeControl: room.options.TimeControl,
+	}
+
+	room.infoMu.Lock()
+	defer room.infoMu.Unlock()
+	room.info = info
+}
+
+// Join - Hands a request to the room. Blocks until the room goroutine picks it up, or returns
+// false if the room has stopped and is waiting to be closed.
+func (room *Room) Join(req Request) bool {
+	select {
+	case room.requests <- req:
+		return true
+	case <-room.done:
+		return false
+	}
+}
+
+func (room *Room) Close() {
+	close(room.requests)
+}
+
+func (room *Room) SetState(state RoomState) {
+	room.state = state
+}
+
+func (room *Room) advanceTurn() {
+	if room.playerTurn == 1 {
+		room.playerTurn = 2
+	} else {
+		room.playerTurn = 1
+	}
+}
+
+// startGame - Starts a fresh game of the given type with player one to move.
+func (room *Room) startGame(gameType game.GameType) {
+	room.gameType = gameType
+	log.Printf("Room %v starting game %v", room.code, room.gameType)
+	room.game = game.NewGame(room.gameType)
+	room.playerTurn = 1
+	room.record = messages.GameRecord{
+		GameType: gameType,
+		Rated:    room.options.Rated,
+		Players:  room.nicknames,
+		Accounts: room.accounts,
+		Started:  time.Now(),
+	}
+	if room.options.Setup != "" && gameType == game.GameTypeCheckers {
+		setup, playerToMove, err := game.ParseCheckersFEN(room.options.Setup)
+		if err != nil {
+			log.Printf("Room %v ignoring its setup: %v", room.code, err)
+		} else {
+			room.game = setup
+			room.playerTurn = playerToMove
+			room.record.Setup = room.options.Setup
+		}
+	}
+	room.startClocks()
+	room.withdrawOffers()
+
+	room.sendTo(1, messages.ServerMessage{
+		Type:         messages.ServerGameStarted,
+		Game:         messages.NewGameWrapper(room.game),
+		PlayerNumber: 1,
+		PlayerTurn:   room.playerTurn,
+	})
+	room.sendTo(2, messages.ServerMessage{
+		Type:         messages.ServerGameStarted,
+		Game:         messages.NewGameWrapper(room.game),
+		PlayerNumber: 2,
+		PlayerTurn:   room.playerTurn,
+	})
+	room.sendToSpectators(messages.ServerMessage{
+		Type:       messages.ServerGameStarted,
+		Game:       messages.NewGameWrapper(room.game),
+		PlayerTurn: room.playerTurn,
+	})
+
+	room.SetState(room.running)
+}
+
+func (room *Room) swapSeats() {
+	room.playerOneChans, room.playerTwoChans = room.playerTwoChans, room.playerOneChans
+	room.nicknames[0], room.nicknames[1] = room.nicknames[1], room.nicknames[0]
+	room.accounts[0], room.accounts[1] = room.accounts[1], room.accounts[0]
+	room.resumeTokens[0], room.resumeTokens[1] = room.resumeTokens[1], room.resumeTokens[0]
+	room.graceDeadlines[0], room.graceDeadlines[1] = room.graceDeadlines[1], room.graceDeadlines[0]
+}
+
+func (room *Room) seat(playerNumber int) *Chans {
+	if playerNumber == 1 {
+		return &room.playerOneChans
+	}
+	return &room.playerTwoChans
+}
+
+// sendTo - Sends to a seated player. Seats whose connection dropped are skipped, since they get
+// a full resync if they come back. Every message says who is playing and how their clocks stand,
+// so no screen has to wait for a particular one to learn them.
+func (room *Room) sendTo(playerNumber int, msg messages.ServerMessage) {
+	seat := room.seat(playerNumber)
+	if *seat == (Chans{}) {
+		return
+	}
+	room.stampMessage(&msg)
+	seat.RoomToPlayer <- msg
+}
+
+func (room *Room) stampMessage(msg *messages.ServerMessage) {
+	msg.PlayerNames = room.nicknames
+	msg.TimeControl = room.options.TimeControl
+	msg.Clocks = room.clocksNow()
+}
+
+// seatPlayer - Gives a joining player a seat along with the token they can resume it with later.
+func (room *Room) seatPlayer(playerNumber int, req Request) {
+	*room.seat(playerNumber) = req.Chans
+	room.nicknames[playerNumber-1] = uniqueNickname(req.Nickname, playerNumber, room.nicknames[otherPlayer(playerNumber)-1])
+	room.accounts[playerNumber-1] = req.Account
+	room.resumeTokens[playerNumber-1] = newResumeToken()
+	room.sendTo(playerNumber, messages.ServerMessage{
+		Type:         messages.ServerRoomJoined,
+		PlayerNumber: playerNumber,
+		ResumeToken:  room.resumeTokens[playerNumber-1],
+		RoomCode:     room.code,
+	})
+}
+
+// addSpectator - Lets an extra joiner watch the room, catching them up on the game so far.
+func (room *Room) addSpectator(req Request) {
+	room.spectators = append(room.spectators, req.Chans)
+	log.Printf("Room %v gained a spectator, %v watching", room.code, len(room.spectators))
+	msg := messages.ServerMessage{
+		Type:       messages.ServerSpectating,
+		Game:       messages.NewGameWrapper(room.game),
+		PlayerTurn: room.playerTurn,
+		Phase:      room.state.phase(),
+	}
+	room.stampMessage(&msg)
+	req.Chans.RoomToPlayer <- msg
+}
+
+func (room *Room) removeSpectator(chans Chans) {
+	room.spectators = slices.DeleteFunc(room.spectators, func(spectator Chans) bool {
+		return spectator == chans
+	})
+	log.Printf("Room %v lost a spectator, %v watching", room.code, len(room.spectators))
+}
+
+// sendToSpectators - Spectators must never hold up the match, so a spectator too far behind to
+// take another message is dropped from the room.
+func (room *Room) sendToSpectators(msg messages.ServerMessage) {
+	room.stampMessage(&msg)
+	room.spectators = slices.DeleteFunc(room.spectators, func(spectator Chans) bool {
+		select {
+		case spectator.RoomToPlayer <- msg:
+			return false
+		default:
+			log.Printf("Room %v dropping a spectator that fell behind", room.code)
+			return true
+		}
+	})
+}
+
+func newResumeToken() string {
+	token := make([]byte, 16)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+// handleDisconnect - Holds a dropped player's seat for the grace period, or treats the drop as
+// quitting if there is no game worth holding it for.
+func (room *Room) handleDisconnect(playerNumber int) error {
+	_, waitingForOpponent := room.state.(RoomStateWaitingForP2)
+	if room.options.ReconnectGrace <= 0 || waitingForOpponent {
+		room.endGameOnQuit(playerNumber)
+		return fmt.Errorf("player %v disconnected", playerNumber)
+	}
+
+	log.Printf("Room %v holding seat %v for %v", room.code, playerNumber, room.options.ReconnectGrace)
+	*room.seat(playerNumber) = Chans{}
+	room.graceDeadlines[playerNumber-1] = time.Now().Add(room.options.ReconnectGrace)
+	room.resetGraceTimer()
+
+	room.sendTo(otherPlayer(playerNumber), messages.ServerMessage{
+		Type:         messages.ServerOpponentDisconnected,
+		PlayerNumber: playerNumber,
+	})
+	return nil
+}
+
+// resume - Hands a held seat to a new connection and resyncs it with the whole room state. A seat
+// that is still connected can be taken over too, since the server may not have noticed the drop yet,
+// in which case the old connection is sent out of the room.
+func (room *Room) resume(req Request) {
+	playerNumber := slices.Index(room.resumeTokens[:], req.ResumeToken) + 1
+	if playerNumber == 0 {
+		req.Chans.RoomToPlayer <- messages.ServerMessage{
+			Type:         messages.ServerResumeFailed,
+			ErrorMessage: "your seat in the room was not held",
+		}
+		return
+	}
+
+	log.Printf("Room %v resuming seat %v", room.code, playerNumber)
+	room.dropReplacedSeat(playerNumber)
+	*room.seat(playerNumber) = req.Chans
+	room.graceDeadlines[playerNumber-1] = time.Time{}
+	room.resetGraceTimer()
+
+	resync := messages.ServerMessage{
+		Type:         messages.ServerResumed,
+		PlayerNumber: playerNumber,
+		PlayerTurn:   room.playerTurn,
+		Game:         messages.NewGameWrapper(room.game),
+		ResumeToken:  req.ResumeToken,
+		Phase:        room.state.phase(),
+	}
+	if resync.Phase == messages.RoomPhasePostGame {
+		resync.GameResult = room.gameResults()[playerNumber-1]
+	}
+	room.sendTo(playerNumber, resync)
+	room.sendTo(otherPlayer(playerNumber), messages.ServerMessage{
+		Type:         messages.ServerOpponentReconnected,
+		PlayerNumber: playerNumber,
+	})
+}
+
+// dropReplacedSeat - Tells a connection that still holds a seat being resumed elsewhere that it is
+// out of the room, so its player stops talking to a room that no longer reads from it. The
+// connection is most likely dead already, so the send must not wait on it.
+func (room *Room) dropReplacedSeat(playerNumber int) {
+	seat := room.seat(playerNumber)
+	if *seat == (Chans{}) {
+		return
+	}
+	select {
+	case seat.RoomToPlayer <- messages.ServerMessage{
+		Type:         messages.ServerRoomClosed,
+		ErrorMessage: "your seat was resumed from another connection",
+	}:
+	default:
+		log.Printf("Could not tell player %v their seat was resumed, channel unavailable", playerNumber)
+	}
+}
+
+// resetGraceTimer - Arms the timer for the earliest held seat, or stops it if no seat is held.
+func (room *Room) resetGraceTimer() {
+	if room.graceTimer != nil {
+		room.graceTimer.Stop()
+		room.graceTimer = nil
+	}
+
+	var next time.Time
+	for _, deadline := range room.graceDeadlines {
+		if !deadline.IsZero() && (next.IsZero() || deadline.Before(next)) {
+			next = deadline
+		}
+	}
+	if !next.IsZero() {
+		room.graceTimer = time.NewTimer(time.Until(next))
+	}
+}
+
+func (room *Room) graceExpired() <-chan time.Time {
+	if room.graceTimer == nil {
+		return nil
+	}
+	return room.graceTimer.C
+}
+
+// forfeitExpiredSeats - A player who did not come back in time loses, and the room closes.
+func (room *Room) forfeitExpiredSeats() error {
+	for i, deadline := range room.graceDeadlines {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			room.endGameOnQuit(i + 1)
+			return fmt.Errorf("player %v did not reconnect in time", i+1)
+		}
+	}
+	room.resetGraceTimer()
+	return nil
+}
+
+func (room *Room) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	switch msg.Type {
+	case messages.ClientDisconnected:
+		return room.handleDisconnect(playerNumber)
+	case messages.ClientChat:
+		//players can talk whatever the room is doing
+		room.relayChat(msg.Text, playerNumber)
+		return nil
+	}
+	return room.state.handlePlayerMessage(msg, playerNumber)
+}
+
+// relayChat - Passes a player's chat message on to both players, cleaned up so it cannot mess with
+// either terminal. Spectators do not see the players' chat. A player chatting faster than the limit
+// is told so, and the message goes no further.
+func (room *Room) relayChat(text string, playerNumber int) {
+	text = messages.SanitizeChat(text)
+	if text == "" {
+		return
+	}
+	if !room.chatLimiters[playerNumber-1].allow(time.Now()) {
+		room.sendTo(playerNumber, messages.ServerMessage{
+			Type:         messages.ServerChat,
+			PlayerNumber: playerNumber,
+			ErrorMessage: "slow down, you are sending messages too fast",
+		})
+		return
+	}
+	chat := messages.ServerMessage{
+		Type:         messages.ServerChat,
+		PlayerNumber: playerNumber,
+		Text:         text,
+	}
+	room.sendTo(1, chat)
+	room.sendTo(2, chat)
+}
+
+const (
+	//a player may send chatBurst chat messages in any chatWindow, and is told off for the rest
+	chatBurst  = 5
+	chatWindow = 5 * time.Second
+)
+
+func otherPlayer(playerNumber int) int {
+	if playerNumber == 1 {
+		return 2
+	}
+	return 1
+}
+
+// Run - Handles the room's events until it closes. Cancelling ctx drains the room rather than
+// closing it outright, see drain.
+func (room *Room) Run(ctx context.Context) {
+	defer func() {
+		close(room.done)
+		room.closeReq <- room.code
+	}()
+	shutdown := ctx.Done()
+	for {
+		room.updateInfo()
+		select {
+		case <-shutdown:
+			shutdown = nil
+			room.drain()
+		//TODO hanlde close requests
+		case joinRequest := <-room.requests:
+			if joinRequest.Leave {
+				room.removeSpectator(joinRequest.Chans)
+				break
+			}
+			if joinRequest.ResumeToken != "" {
+				room.resume(joinRequest)
+				break
+			}
+			err := room.state.handleJoinRequest(joinRequest)
+			if err != nil {
+				//TODO
+				log.Printf("error encountered while handling join request: %v", err)
+				return
+			}
+		case msg := <-room.playerOneChans.PlayerToRoom:
+			err := room.handlePlayerMessage(msg, 1)
+			if err != nil {
+				log.Printf("error while handling player message, closing room: %v", err)
+				return
+			}
+		case msg := <-room.playerTwoChans.PlayerToRoom:
+			err := room.handlePlayerMessage(msg, 2)
+			if err != nil {
+				log.Printf("error while handling player message, closing room: %v", err)
+				return
+			}
+		case <-room.graceExpired():
+			err := room.forfeitExpiredSeats()
+			if err != nil {
+				log.Printf("closing room: %v", err)
+				return
+			}
+		case <-room.clockExpired():
+			room.timeOut()
+		case <-room.drainExpired():
+			log.Printf("Room %v did not finish its game in time, closing", room.code)
+			room.closeForShutdown()
+			return
+		}
+		if room.drained() {
+			room.closeForShutdown()
+			return
+		}
+	}
+}
+
+// onQuit - Sends message to players who did not quit, informing them of game completion.
+func (room *Room) endGameOnQuit(quittingPlayerNum int) {
+	room.stopClock()
+	p1Message := messages.ServerMessage{
+		Type: messages.ServerRoomClosed,
+		Game: messages.NewGameWrapper(room.game),
+	}
+	p2Message := messages.ServerMessage{
+		Type: messages.ServerRoomClosed,
+		Game: messages.NewGameWrapper(room.game),
+	}
+
+	if quittingPlayerNum == 1 {
+		p1Message.GameResult = messages.GameResultPlayerLose
+		p1Message.QuittingPlayerNum = 1
+		p2Message.GameResult = messages.GameResultPlayerWin
+		p2Message.QuittingPlayerNum = 1
+	}
+	if quittingPlayerNum == 2 {
+		p2Message.GameResult = messages.GameResultPlayerLose
+		p2Message.QuittingPlayerNum = 2
+		p1Message.GameResult = messages.GameResultPlayerWin
+		p1Message.QuittingPlayerNum = 2
+	}
+
+	if _, running := room.state.(RoomStateRunning); running {
+		//leaving a game part way through counts as losing it
+		results := [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerWin}
+		results[quittingPlayerNum-1] = messages.GameResultPlayerLose
+		room.record.QuittingPlayerNum = quittingPlayerNum
+		room.reportResult(results)
+	}
+
+	//Non blocking sends to players - it is possible they are closed here.
+	//them being closed should not impact the rooms functionality
+	if room.playerOneChans != (Chans{}) {
+		select {
+		case room.playerOneChans.RoomToPlayer <- p1Message:
+		default:
+			log.Printf("Could not send message to player 1, channel unavailable")
+		}
+	}
+	if room.playerTwoChans != (Chans{}) {
+		select {
+		case room.playerTwoChans.RoomToPlayer <- p2Message:
+		default:
+			log.Printf("Could not send message to player 2, channel unavailable")
+		}
+	}
+	room.sendToSpectators(messages.ServerMessage{
+		Type:              messages.ServerRoomClosed,
+		Game:              messages.NewGameWrapper(room.game),
+		QuittingPlayerNum: quittingPlayerNum,
+	})
+}
+
+func (room *Room) endGameOnCompletion() {
+	room.stopClock()
+	p1Message := messages.ServerMessage{
+		Type:              messages.ServerGameFinished,
+		Game:              messages.NewGameWrapper(room.game),
+		TimedOutPlayerNum: room.timedOutPlayerNum,
+	}
+	p2Message := messages.ServerMessage{
+		Type:              messages.ServerGameFinished,
+		Game:              messages.NewGameWrapper(room.game),
+		TimedOutPlayerNum: room.timedOutPlayerNum,
+	}
+	results := room.gameResults()
+	p1Message.GameResult, p2Message.GameResult = results[0], results[1]
+	room.reportResult(results)
+
+	room.sendTo(1, p1Message)
+	room.sendTo(2, p2Message)
+	room.sendToSpectators(messages.ServerMessage{
+		Type:              messages.ServerGameFinished,
+		Game:              messages.NewGameWrapper(room.game),
+		TimedOutPlayerNum: room.timedOutPlayerNum,
+	})
+
+	room.postGame.votes.reset()
+	room.SetState(room.postGame)
+}
+
+func (room *Room) reportResult(results [2]messages.GameResult) {
+	if room.options.OnResult == nil {
+		return
+	}
+	room.record.Finished = time.Now()
+	room.record.Results = results
+	room.options.OnResult(Result{
+		GameType: room.gameType,
+		Accounts: room.accounts,
+		Results:  results,
+		Rated:    room.options.Rated,
+		Record:   room.record,
+	})
+}
+
+// gameResults - Each player's result for the finished game, indexed by player number - 1.
+func (room *Room) gameResults() [2]messages.GameResult {
+	switch room.game.GetGameStatus() {
+	case game.GameStatusDraw:
+		return [2]messages.GameResult{messages.GameResultDraw, messages.GameResultDraw}
+	case game.GameStatusPlayer1Win:
+		return [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose}
+	case game.GameStatusPlayer2Win:
+		return [2]messages.GameResult{messages.GameResultPlayerLose, messages.GameResultPlayerWin}
+	}
+	return [2]messages.GameResult{}
+}
+
+type RoomState interface {
+	handleJoinRequest(req Request) error
+	handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error
+	//phase tells a reconnecting player which part of the room they are rejoining
+	phase() messages.RoomPhase
+}
+
+type RoomStateWaitingForP1 struct {
+	room *Room
+}
+
+func (state RoomStateWaitingForP1) handleJoinRequest(req Request) error {
+	state.room.SetState(state.room.waitingForPlayerTwo)
+	state.room.seatPlayer(1, req)
+	return nil
+}
+
+func (state RoomStateWaitingForP1) phase() messages.RoomPhase {
+	return messages.RoomPhaseWaitingForOpponent
+}
+
+func (state RoomStateWaitingForP1) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	panic("should be no player messages while waiting for p1")
+}
+
+type RoomStateWaitingForP2 struct {
+	room *Room
+}
+
+func (state RoomStateWaitingForP2) handleJoinRequest(req Request) error {
+	state.room.seatPlayer(2, req)
+
+	if state.room.options.AutoStart {
+		log.Println("Player two joined room, starting the matched game.")
+		state.room.startGame(state.room.options.GameType)
+		return nil
+	}
+
+	state.room.sendTo(1, messages.ServerMessage{
+		Type: messages.ServerEnteredGameSelection,
+	})
+	state.room.sendTo(2, messages.ServerMessage{
+		Type: messages.ServerEnteredGameSelection,
+	})
+
+	state.room.SetState(state.room.inGameSelection)
+	log.Println("Player two joined room, entering game selection.")
+	return nil
+}
+
+func (state RoomStateWaitingForP2) phase() messages.RoomPhase {
+	return messages.RoomPhaseWaitingForOpponent
+}
+
+func (state RoomStateWaitingForP2) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	if playerNumber == 2 {
+		return fmt.Errorf("should be no messages from player two while waiting for p2")
+	}
+	switch msg.Type {
+	case messages.ClientQuitRoom:
+		state.room.endGameOnQuit(playerNumber)
+		return fmt.Errorf("player %v quit", playerNumber)
+	}
+	return nil
+}
+
+type RoomStateInGameSelection struct {
+	room *Room
+}
+
+func (state RoomStateInGameSelection) handleJoinRequest(req Request) error {
+	state.room.addSpectator(req)
+	return nil
+}
+
+func (state RoomStateInGameSelection) phase() messages.RoomPhase {
+	return messages.RoomPhaseGameSelection
+}
+
+func (state RoomStateInGameSelection) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	switch msg.Type {
+	case messages.ClientQuitRoom:
+		//TODO this will tell a player one of them won, which should not happen in game selection
+		state.room.endGameOnQuit(playerNumber)
+		return fmt.Errorf("player %v quit", playerNumber)
+	case messages.ClientSelectGameType:
+		if playerNumber != 1 {
+			return fmt.Errorf("only player 1 can select the game type")
+		}
+		state.room.startGame(msg.GameType)
+	}
+	return nil
+}
+
+type RoomStateRunning struct {
+	room *Room
+}
+
+func (state RoomStateRunning) handleJoinRequest(req Request) error {
+	state.room.addSpectator(req)
+	return nil
+}
+
+func (state RoomStateRunning) phase() messages.RoomPhase {
+	return messages.RoomPhaseInGame
+}
+
+func (state RoomStateRunning) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	switch msg.Type {
+	case messages.ClientQuitRoom:
+		state.room.endGameOnQuit(playerNumber)
+		return fmt.Errorf("player %v quit", playerNumber)
+
+	case messages.ClientSendTurn:
+		serverMsg := messages.ServerMessage{}
+		if playerNumber != state.room.playerTurn {
+			serverMsg.Type = messages.ServerError
+			serverMsg.ErrorMessage = "You can only move on your turn."
+			state.sendTurnResult(serverMsg, playerNumber)
+			return nil
+		}
+
+		isMoveValid, validationMsg := state.room.game.ValidateMove(msg.TurnAction.GetGameTurn(), playerNumber)
+		if !isMoveValid {
+			serverMsg.Type = messages.ServerError
+			serverMsg.ErrorMessage = validationMsg
+			state.sendTurnResult(serverMsg, playerNumber)
+			return nil
+		}
+
+		if !state.room.chargeClock(playerNumber) {
+			state.room.timeOut()
+			return nil
+		}
+
+		state.room.game.ExecuteTurn(msg.TurnAction.GetGameTurn(), playerNumber)
+		state.room.record.Turns = append(state.room.record.Turns, messages.RecordedTurn{
+			PlayerNumber: playerNumber,
+			Turn:         msg.TurnAction,
+			Time:         time.Now(),
+		})
+		state.room.advanceTurn()
+		state.room.withdrawOffers()
+		if state.room.game.GetGameStatus() != game.GameStatusOngoing {
+			state.room.endGameOnCompletion()
+			return nil
+		}
+		if state.room.clockTimer != nil {
+			state.room.resetClockTimer()
+		}
+
+		serverMsg.Type = messages.ServerTurnResult
+		serverMsg.Game = messages.NewGameWrapper(state.room.game)
+		serverMsg.PlayerTurn = state.room.playerTurn
+		state.sendTurnResult(serverMsg, playerNumber)
+	case messages.ClientConcede:
+		if playerNumber == 1 {
+			state.room.game.OverrideGameStatus(game.GameStatusPlayer2Win)
+		} else {
+			state.room.game.OverrideGameStatus(game.GameStatusPlayer1Win)
+		}
+		state.room.endGameOnCompletion()
+	case messages.ClientMakeOffer:
+		state.room.makeOffer(msg.Offer, playerNumber)
+	case messages.ClientAnswerOffer:
+		state.room.answerOffer(msg.Offer, msg.Accept, playerNumber)
+	case messages.ClientEmote:
+		state.room.relayEmote(msg.Emote, playerNumber)
+	}
+
+	return nil
+}
+
+func (state RoomStateRunning) sendTurnResult(serverMsg messages.ServerMessage, playerNumber int) {
+	if serverMsg.Type == messages.ServerError && playerNumber == 1 {
+		state.room.sendTo(1, serverMsg)
+	} else if serverMsg.Type == messages.ServerError && playerNumber == 2 {
+		state.room.sendTo(2, serverMsg)
+	} else {
+		state.room.sendTo(1, serverMsg)
+		state.room.sendTo(2, serverMsg)
+		state.room.sendToSpectators(serverMsg)
+	}
+}
+
+// rematchVotes - Each player's latest vote. A player may change their vote until both match.
+type rematchVotes struct {
+	voted [2]bool
+	votes [2]messages.RematchOption
+}
+
+func (votes *rematchVotes) reset() {
+	*votes = rematchVotes{}
+}
+
+// agreed - Returns the option both players voted for, if they agree.
+func (votes *rematchVotes) agreed() (messages.RematchOption, bool) {
+	if !votes.voted[0] || !votes.voted[1] || votes.votes[0] != votes.votes[1] {
+		return 0, false
+	}
+	return votes.votes[0], true
+}
+
+// RoomStatePostGame - Both players stay in the room after a game ends, and vote on what to do next.
+type RoomStatePostGame struct {
+	room  *Room
+	votes *rematchVotes
+}
+
+func (state RoomStatePostGame) handleJoinRequest(req Request) error {
+	state.room.addSpectator(req)
+	return nil
+}
+
+func (state RoomStatePostGame) phase() messages.RoomPhase {
+	return messages.RoomPhasePostGame
+}
+
+func (state RoomStatePostGame) handlePlayerMessage(msg messages.ClientMessage, playerNumber int) error {
+	switch msg.Type {
+	case messages.ClientQuitRoom:
+		state.room.endGameOnQuit(playerNumber)
+		return fmt.Errorf("player %v quit", playerNumber)
+	case messages.ClientEmote:
+		//"gg" is most often sent once the game is over
+		state.room.relayEmote(msg.Emote, playerNumber)
+	case messages.ClientRematchVote:
+		state.votes.voted[playerNumber-1] = true
+		state.votes.votes[playerNumber-1] = msg.Rematch
+
+		voteMsg := messages.ServerMessage{
+			Type:         messages.ServerRematchVote,
+			PlayerNumber: playerNumber,
+			Rematch:      msg.Rematch,
+		}
+		state.room.sendTo(1, voteMsg)
+		state.room.sendTo(2, voteMsg)
+
+		option, ok := state.votes.agreed()
+		if !ok {
+			return nil
+		}
+		log.Printf("Room %v agreed on: %v", state.room.code, option)
+		switch option {
+		case messages.RematchNewGame:
+			//no game is in play until a new one is picked
+			state.room.game = nil
+			state.room.sendTo(1, messages.ServerMessage{
+				Type: messages.ServerEnteredGameSelection,
+			})
+			state.room.sendTo(2, messages.ServerMessage{
+				Type: messages.ServerEnteredGameSelection,
+			})
+			state.room.sendToSpectators(messages.ServerMessage{
+				Type: messages.ServerEnteredGameSelection,
+			})
+			state.room.SetState(state.room.inGameSelection)
+		case messages.RematchSwapSides:
+			state.room.swapSeats()
+			state.room.startGame(state.room.gameType)
+		default:
+			state.room.startGame(state.room.gameType)
+		}
+	}
+	return nil
+}
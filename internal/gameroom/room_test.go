@@ -0,0 +1,620 @@
+package gameroom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func newTestSeat() Chans {
+	return Chans{
+		RoomToPlayer: make(chan messages.ServerMessage, 16),
+		PlayerToRoom: make(chan messages.ClientMessage),
+	}
+}
+
+func expectMessage(t *testing.T, seat Chans, expected messages.ServerMessageType) messages.ServerMessage {
+	t.Helper()
+	select {
+	case msg := <-seat.RoomToPlayer:
+		if msg.Type != expected {
+			t.Fatalf("expected %v message, got %v (%q)", expected, msg.Type, msg.ErrorMessage)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %v message", expected)
+	}
+	return messages.ServerMessage{}
+}
+
+// startRunningRoom - Seats two players in a tic-tac-toe game and returns their seats and resume tokens.
+func startRunningRoom(t *testing.T, options Options) (*Room, chan string, [2]Chans, [2]string) {
+	t.Helper()
+	return startRunningRoomUntil(t, context.Background(), options)
+}
+
+// startRunningRoomUntil - Like startRunningRoom, with the room draining once ctx is cancelled.
+func startRunningRoomUntil(t *testing.T, ctx context.Context, options Options) (*Room, chan string, [2]Chans, [2]string) {
+	t.Helper()
+	closeReq := make(chan string, 1)
+	room := NewRoom("TEST", closeReq, options)
+	go room.Run(ctx)
+
+	seats := [2]Chans{newTestSeat(), newTestSeat()}
+	var tokens [2]string
+	for i, seat := range seats {
+		room.Join(Request{Code: "TEST", Chans: seat})
+		tokens[i] = expectMessage(t, seat, messages.ServerRoomJoined).ResumeToken
+	}
+	if tokens[0] == "" || tokens[0] == tokens[1] {
+		t.Fatalf("expected two distinct resume tokens, got %q and %q", tokens[0], tokens[1])
+	}
+
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerEnteredGameSelection)
+	}
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSelectGameType, GameType: game.GameTypeTicTacToe}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerGameStarted)
+	}
+	return room, closeReq, seats, tokens
+}
+
+func TestRoomResumesHeldSeat(t *testing.T) {
+	room, _, seats, tokens := startRunningRoom(t, Options{ReconnectGrace: time.Minute})
+
+	seats[0].PlayerToRoom <- messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+	}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerTurnResult)
+	}
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}
+	if msg := expectMessage(t, seats[0], messages.ServerOpponentDisconnected); msg.PlayerNumber != 2 {
+		t.Errorf("expected player 2 to be reported away, got player %d", msg.PlayerNumber)
+	}
+
+	resumed := newTestSeat()
+	room.Join(Request{Code: "TEST", Chans: resumed, ResumeToken: tokens[1]})
+	msg := expectMessage(t, resumed, messages.ServerResumed)
+	if msg.PlayerNumber != 2 || msg.PlayerTurn != 2 || msg.Phase != messages.RoomPhaseInGame {
+		t.Fatalf("unexpected resync: player %d, turn %d, phase %v", msg.PlayerNumber, msg.PlayerTurn, msg.Phase)
+	}
+	if valid, _ := msg.Game.GetGame().ValidateMove(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}, 2); valid {
+		t.Error("resynced game is missing player 1's move")
+	}
+	expectMessage(t, seats[0], messages.ServerOpponentReconnected)
+
+	//the resumed seat plays on as player 2
+	resumed.PlayerToRoom <- messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(0, 0)}),
+	}
+	expectMessage(t, seats[0], messages.ServerTurnResult)
+	expectMessage(t, resumed, messages.ServerTurnResult)
+}
+
+func TestRoomResumeClosesOldConnection(t *testing.T) {
+	room, _, seats, tokens := startRunningRoom(t, Options{ReconnectGrace: time.Minute})
+
+	//the room has not noticed player 2's old connection drop, and hands the seat over anyway
+	resumed := newTestSeat()
+	room.Join(Request{Code: "TEST", Chans: resumed, ResumeToken: tokens[1]})
+	expectMessage(t, resumed, messages.ServerResumed)
+	if msg := expectMessage(t, seats[1], messages.ServerRoomClosed); msg.ErrorMessage == "" {
+		t.Error("expected the old connection to be told why it left the room")
+	}
+	expectMessage(t, seats[0], messages.ServerOpponentReconnected)
+}
+
+func TestRoomRejectsUnknownResumeToken(t *testing.T) {
+	room, _, seats, _ := startRunningRoom(t, Options{ReconnectGrace: time.Minute})
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}
+	expectMessage(t, seats[0], messages.ServerOpponentDisconnected)
+
+	stranger := newTestSeat()
+	room.Join(Request{Code: "TEST", Chans: stranger, ResumeToken: "not-a-token"})
+	expectMessage(t, stranger, messages.ServerResumeFailed)
+}
+
+func TestRoomForfeitsSeatAfterGracePeriod(t *testing.T) {
+	_, closeReq, seats, _ := startRunningRoom(t, Options{ReconnectGrace: 20 * time.Millisecond})
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}
+	expectMessage(t, seats[0], messages.ServerOpponentDisconnected)
+
+	msg := expectMessage(t, seats[0], messages.ServerRoomClosed)
+	if msg.GameResult != messages.GameResultPlayerWin || msg.QuittingPlayerNum != 2 {
+		t.Errorf("expected player 1 to win by forfeit, got result %v quitting player %d", msg.GameResult, msg.QuittingPlayerNum)
+	}
+	select {
+	case <-closeReq:
+	case <-time.After(5 * time.Second):
+		t.Fatal("room did not close after the grace period")
+	}
+}
+
+func TestRoomWithoutGraceTreatsDisconnectAsQuit(t *testing.T) {
+	_, closeReq, seats, _ := startRunningRoom(t, Options{})
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}
+	expectMessage(t, seats[0], messages.ServerRoomClosed)
+	select {
+	case <-closeReq:
+	case <-time.After(5 * time.Second):
+		t.Fatal("room did not close")
+	}
+}
+
+func TestRoomSeatsExtraJoinersAsSpectators(t *testing.T) {
+	room, _, seats, _ := startRunningRoom(t, Options{})
+
+	seats[0].PlayerToRoom <- messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+	}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerTurnResult)
+	}
+
+	spectator := newTestSeat()
+	room.Join(Request{Code: "TEST", Chans: spectator})
+	msg := expectMessage(t, spectator, messages.ServerSpectating)
+	if msg.Phase != messages.RoomPhaseInGame || msg.PlayerTurn != 2 {
+		t.Fatalf("unexpected spectator catch up: phase %v, turn %d", msg.Phase, msg.PlayerTurn)
+	}
+	if valid, _ := msg.Game.GetGame().ValidateMove(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}, 2); valid {
+		t.Error("spectator's game is missing player 1's move")
+	}
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(0, 0)}),
+	}
+	expectMessage(t, spectator, messages.ServerTurnResult)
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerTurnResult)
+	}
+
+	//leaving does not disturb the match, and the spectator hears nothing more
+	room.Join(Request{Code: "TEST", Chans: spectator, Leave: true})
+	seats[0].PlayerToRoom <- messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(2, 2)}),
+	}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerTurnResult)
+	}
+	select {
+	case msg := <-spectator.RoomToPlayer:
+		t.Errorf("spectator received %v after leaving", msg.Type)
+	default:
+	}
+}
+
+func TestRoomJoinFailsOnceStopped(t *testing.T) {
+	closeReq := make(chan string, 1)
+	room := NewRoom("TEST", closeReq, Options{})
+	go room.Run(context.Background())
+
+	seat := newTestSeat()
+	room.Join(Request{Code: "TEST", Chans: seat})
+	expectMessage(t, seat, messages.ServerRoomJoined)
+	seat.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientQuitRoom}
+	<-closeReq
+
+	//the hub has not closed the room yet, but nobody is left to take the request
+	if room.Join(Request{Code: "TEST", Chans: newTestSeat()}) {
+		t.Error("join should fail on a room that has stopped")
+	}
+}
+
+func TestRoomInfoTracksPlayersAndPhase(t *testing.T) {
+	room, _, seats, _ := startRunningRoom(t, Options{ReconnectGrace: time.Minute, Public: true})
+	if !room.IsPublic() {
+		t.Error("room should be public")
+	}
+	waitForInfo(t, room, messages.RoomInfo{Code: "TEST", GameType: game.GameTypeTicTacToe, Players: 2, Phase: messages.RoomPhaseInGame})
+
+	room.Join(Request{Code: "TEST", Chans: newTestSeat()})
+	waitForInfo(t, room, messages.RoomInfo{Code: "TEST", GameType: game.GameTypeTicTacToe, Players: 2, Spectators: 1, Phase: messages.RoomPhaseInGame})
+
+	//a held seat still counts as taken
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}
+	expectMessage(t, seats[0], messages.ServerOpponentDisconnected)
+	waitForInfo(t, room, messages.RoomInfo{Code: "TEST", GameType: game.GameTypeTicTacToe, Players: 2, Spectators: 1, Phase: messages.RoomPhaseInGame})
+}
+
+// waitForInfo - The snapshot is refreshed once the room goroutine finishes an event, so give it a moment.
+func waitForInfo(t *testing.T, room *Room, expected messages.RoomInfo) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for room.Info() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected room info %+v, got %+v", expected, room.Info())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRoomAutoStartSkipsGameSelection(t *testing.T) {
+	closeReq := make(chan string, 1)
+	room := NewRoom("MATCH", closeReq, Options{AutoStart: true, GameType: game.GameTypeCheckers})
+	go room.Run(context.Background())
+
+	seats := [2]Chans{newTestSeat(), newTestSeat()}
+	for _, seat := range seats {
+		room.Join(Request{Code: "MATCH", Chans: seat})
+		if msg := expectMessage(t, seat, messages.ServerRoomJoined); msg.RoomCode != "MATCH" {
+			t.Errorf("expected the room code with the join, got %q", msg.RoomCode)
+		}
+	}
+	for i, seat := range seats {
+		msg := expectMessage(t, seat, messages.ServerGameStarted)
+		if msg.PlayerNumber != i+1 || msg.Game.GetGame().GetGameType() != game.GameTypeCheckers {
+			t.Errorf("seat %d got player %d in a %v game", i+1, msg.PlayerNumber, msg.Game.GetGame().GetGameType())
+		}
+	}
+}
+
+func TestRoomStartsFromSetup(t *testing.T) {
+	closeReq := make(chan string, 1)
+	options := Options{AutoStart: true, GameType: game.GameTypeCheckers, Setup: "B:W18:B14"}
+	room := NewRoom("SETUP", closeReq, options)
+	go room.Run(context.Background())
+
+	seats := [2]Chans{newTestSeat(), newTestSeat()}
+	for _, seat := range seats {
+		room.Join(Request{Code: "SETUP", Chans: seat})
+		expectMessage(t, seat, messages.ServerRoomJoined)
+	}
+	msg := expectMessage(t, seats[0], messages.ServerGameStarted)
+	expectMessage(t, seats[1], messages.ServerGameStarted)
+	if msg.PlayerTurn != 2 {
+		t.Errorf("expected black to move first from the setup, got player %d", msg.PlayerTurn)
+	}
+	if fen := msg.Game.GetGame().(*game.CheckersGame).FEN(msg.PlayerTurn); fen != options.Setup {
+		t.Errorf("expected the game to start from %v, got %v", options.Setup, fen)
+	}
+}
+
+func TestRoomCheckPassword(t *testing.T) {
+	open := NewRoom("OPEN1", make(chan string, 1), Options{})
+	if !open.CheckPassword("") || open.Info().Locked {
+		t.Error("a room without a password should let anyone in")
+	}
+
+	locked := NewRoom("LOCK1", make(chan string, 1), Options{Password: "hunter2"})
+	if !locked.Info().Locked {
+		t.Error("a room with a password should show as locked")
+	}
+	for _, guess := range []string{"", "hunter", "hunter22", "HUNTER2"} {
+		if locked.CheckPassword(guess) {
+			t.Errorf("password %q should be rejected", guess)
+		}
+	}
+	if !locked.CheckPassword("hunter2") {
+		t.Error("the right password should be accepted")
+	}
+}
+
+func TestRoomSharesUniqueNicknames(t *testing.T) {
+	room := NewRoom("NAMES", make(chan string, 1), Options{})
+	go room.Run(context.Background())
+
+	seats := [2]Chans{newTestSeat(), newTestSeat()}
+	for _, seat := range seats {
+		room.Join(Request{Code: "NAMES", Chans: seat, Nickname: "Ada"})
+		expectMessage(t, seat, messages.ServerRoomJoined)
+	}
+	expected := [2]string{"Ada", "Ada (2)"}
+	for _, seat := range seats {
+		if msg := expectMessage(t, seat, messages.ServerEnteredGameSelection); msg.PlayerNames != expected {
+			t.Errorf("expected names %q, got %q", expected, msg.PlayerNames)
+		}
+	}
+
+	spectator := newTestSeat()
+	room.Join(Request{Code: "NAMES", Chans: spectator})
+	if msg := expectMessage(t, spectator, messages.ServerSpectating); msg.PlayerNames != expected {
+		t.Errorf("expected the spectator to see names %q, got %q", expected, msg.PlayerNames)
+	}
+}
+
+func TestRoomReportsResults(t *testing.T) {
+	results := make(chan Result, 1)
+	_, _, seats, _ := startRunningRoom(t, Options{Rated: true, OnResult: func(result Result) { results <- result }})
+
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientConcede}
+	expectMessage(t, seats[0], messages.ServerGameFinished)
+
+	select {
+	case result := <-results:
+		expected := [2]messages.GameResult{messages.GameResultPlayerLose, messages.GameResultPlayerWin}
+		if result.Results != expected || result.GameType != game.GameTypeTicTacToe || !result.Rated {
+			t.Errorf("expected player 1 to lose a rated game of tic-tac-toe, got %+v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("room did not report the result")
+	}
+}
+
+func TestRoomRecordsAcceptedTurns(t *testing.T) {
+	results := make(chan Result, 1)
+	_, _, seats, _ := startRunningRoom(t, Options{OnResult: func(result Result) { results <- result }})
+
+	turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)})
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+	expectMessage(t, seats[0], messages.ServerTurnResult)
+	//a rejected turn is not part of the game
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+	expectMessage(t, seats[1], messages.ServerTurnResult)
+	expectMessage(t, seats[1], messages.ServerError)
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientQuitRoom}
+
+	select {
+	case result := <-results:
+		record := result.Record
+		if len(record.Turns) != 1 || record.Turns[0].PlayerNumber != 1 || record.Turns[0].Turn != turn {
+			t.Fatalf("expected player 1's one turn to be recorded, got %+v", record.Turns)
+		}
+		if record.QuittingPlayerNum != 2 || record.Results != result.Results {
+			t.Errorf("expected the record to end with player 2 leaving, got %+v", record)
+		}
+		if record.Started.IsZero() || record.Finished.Before(record.Started) {
+			t.Errorf("expected the record to be timed, got %v to %v", record.Started, record.Finished)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("room did not report the result")
+	}
+}
+
+func TestRoomChargesClocksOnTurns(t *testing.T) {
+	control := messages.TimeControl{Base: time.Minute, Increment: 2 * time.Second}
+	_, _, seats, _ := startRunningRoom(t, Options{TimeControl: control})
+
+	turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)})
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+	msg := expectMessage(t, seats[0], messages.ServerTurnResult)
+	if msg.TimeControl != control {
+		t.Errorf("expected the time control with the turn, got %+v", msg.TimeControl)
+	}
+	if msg.Clocks[0] <= time.Minute || msg.Clocks[0] > time.Minute+2*time.Second {
+		t.Errorf("expected player 1 to gain their increment, got %v", msg.Clocks[0])
+	}
+	if msg.Clocks[1] > time.Minute || msg.Clocks[1] < time.Minute-time.Second {
+		t.Errorf("expected player 2's clock to have just started, got %v", msg.Clocks[1])
+	}
+}
+
+func TestRoomEndsGameWhenClockRunsOut(t *testing.T) {
+	results := make(chan Result, 1)
+	options := Options{
+		TimeControl: messages.TimeControl{PerMove: 50 * time.Millisecond},
+		OnResult:    func(result Result) { results <- result },
+	}
+	_, _, seats, _ := startRunningRoom(t, options)
+
+	//nobody moves, so the room has to notice on its own
+	for _, seat := range seats {
+		msg := expectMessage(t, seat, messages.ServerGameFinished)
+		if msg.TimedOutPlayerNum != 1 || msg.Game.GetGame().GetGameStatus() != game.GameStatusPlayer2Win {
+			t.Errorf("expected player 1 to lose on time, got %+v", msg)
+		}
+		if msg.Clocks[0] != 0 {
+			t.Errorf("expected player 1's clock to read zero, got %v", msg.Clocks[0])
+		}
+	}
+	select {
+	case result := <-results:
+		if result.Record.TimedOutPlayerNum != 1 {
+			t.Errorf("expected the record to say player 1 ran out of time, got %+v", result.Record)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("room did not report the result")
+	}
+}
+
+func TestRoomEndsGameOnAcceptedDraw(t *testing.T) {
+	_, _, seats, _ := startRunningRoom(t, Options{})
+
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferDraw}
+	if msg := expectMessage(t, seats[1], messages.ServerOffer); msg.PlayerNumber != 1 || msg.Offer != messages.OfferDraw {
+		t.Errorf("expected player 2 to be offered a draw by player 1, got %+v", msg)
+	}
+	//answering your own offer does nothing
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientAnswerOffer, Offer: messages.OfferDraw, Accept: true}
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientAnswerOffer, Offer: messages.OfferDraw, Accept: true}
+
+	for _, seat := range seats {
+		if msg := expectMessage(t, seat, messages.ServerOfferAnswered); !msg.Accepted {
+			t.Errorf("expected the draw to be accepted, got %+v", msg)
+		}
+		msg := expectMessage(t, seat, messages.ServerGameFinished)
+		if msg.GameResult != messages.GameResultDraw || msg.Game.GetGame().GetGameStatus() != game.GameStatusDraw {
+			t.Errorf("expected the game to end drawn, got %+v", msg)
+		}
+	}
+}
+
+func TestRoomTakesBackMoves(t *testing.T) {
+	_, _, seats, _ := startRunningRoom(t, Options{})
+	move := func(seat Chans, x, y int) {
+		turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(x, y)})
+		seat.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+		for _, seat := range seats {
+			expectMessage(t, seat, messages.ServerTurnResult)
+		}
+	}
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferTakeback}
+	expectMessage(t, seats[1], messages.ServerError)
+
+	move(seats[0], 1, 1)
+	move(seats[1], 0, 0)
+
+	//player 1 has to wait for the reply to be undone too
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferTakeback}
+	expectMessage(t, seats[1], messages.ServerOffer)
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientAnswerOffer, Offer: messages.OfferTakeback, Accept: true}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerOfferAnswered)
+		msg := expectMessage(t, seat, messages.ServerTurnResult)
+		if msg.PlayerTurn != 1 {
+			t.Errorf("expected player 1 to move again, got player %v", msg.PlayerTurn)
+		}
+		if msg.Game.GetGame().(*game.TicTacToeGame).Board != game.NewTicTacToeGame().Board {
+			t.Errorf("expected both moves to be undone, got %+v", msg.Game.TicTacToe.Board)
+		}
+	}
+
+	//a move withdraws a waiting offer, so a late answer is dropped
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferDraw}
+	expectMessage(t, seats[1], messages.ServerOffer)
+	move(seats[0], 2, 2)
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientAnswerOffer, Offer: messages.OfferDraw, Accept: true}
+	move(seats[1], 0, 0)
+}
+
+func TestRoomRelaysCleanedUpChat(t *testing.T) {
+	_, _, seats, _ := startRunningRoom(t, Options{})
+
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientChat, Text: "\x1b[2Jgood luck\a"}
+	for _, seat := range seats {
+		msg := expectMessage(t, seat, messages.ServerChat)
+		if msg.PlayerNumber != 2 || msg.Text != "good luck" {
+			t.Errorf("expected player 2's message without its control characters, got %+v", msg)
+		}
+	}
+
+	//a message with nothing printable left is dropped
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientChat, Text: "\x1b[0m \r\n"}
+	turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)})
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+	expectMessage(t, seats[1], messages.ServerTurnResult)
+}
+
+func TestRoomRateLimitsChat(t *testing.T) {
+	_, _, seats, _ := startRunningRoom(t, Options{})
+
+	for range chatBurst + 1 {
+		seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientChat, Text: "spam"}
+	}
+	for range chatBurst {
+		expectMessage(t, seats[0], messages.ServerChat)
+		if msg := expectMessage(t, seats[1], messages.ServerChat); msg.Text != "spam" {
+			t.Errorf("expected player 1's message, got %+v", msg)
+		}
+	}
+	//only the sender hears about the one past the burst
+	if msg := expectMessage(t, seats[0], messages.ServerChat); msg.ErrorMessage == "" || msg.Text != "" {
+		t.Errorf("expected the sender to be told to slow down, got %+v", msg)
+	}
+	turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)})
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+	expectMessage(t, seats[1], messages.ServerTurnResult)
+}
+
+func TestRoomRateLimitsEmotes(t *testing.T) {
+	_, _, seats, _ := startRunningRoom(t, Options{})
+
+	for range emoteBurst + 2 {
+		seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientEmote, Emote: messages.EmoteNiceMove}
+	}
+	seats[1].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientEmote, Emote: messages.Emote(99)}
+	turn := messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)})
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSendTurn, TurnAction: turn}
+
+	//only the burst gets through, and the unknown emote not at all
+	for range emoteBurst {
+		if msg := expectMessage(t, seats[1], messages.ServerEmote); msg.PlayerNumber != 1 || msg.Emote != messages.EmoteNiceMove {
+			t.Errorf("expected player 1's emote, got %+v", msg)
+		}
+	}
+	expectMessage(t, seats[1], messages.ServerTurnResult)
+}
+
+func TestEmoteLimiterFreesUpOverTime(t *testing.T) {
+	limiter := newRateLimiter(emoteBurst, emoteWindow)
+	start := time.Now()
+	for i := range emoteBurst {
+		if !limiter.allow(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatalf("expected emote %d to be allowed", i+1)
+		}
+	}
+	if limiter.allow(start.Add(emoteWindow - time.Second)) {
+		t.Error("expected the emote past the burst to be dropped")
+	}
+	if !limiter.allow(start.Add(emoteWindow)) {
+		t.Error("expected the first emote to have left the window")
+	}
+}
+
+// expectShutdown - The room should close on the seat because the server is going away.
+func expectShutdown(t *testing.T, seat Chans) {
+	t.Helper()
+	if msg := expectMessage(t, seat, messages.ServerRoomClosed); msg.ErrorMessage != ErrShuttingDown.Error() {
+		t.Errorf("expected the room to close for the shutdown, got %q", msg.ErrorMessage)
+	}
+}
+
+func TestRoomDrainClosesIdleRoom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	closeReq := make(chan string, 1)
+	room := NewRoom("IDLE1", closeReq, Options{DrainWindow: time.Minute})
+	go room.Run(ctx)
+
+	seat := newTestSeat()
+	room.Join(Request{Code: "IDLE1", Chans: seat})
+	expectMessage(t, seat, messages.ServerRoomJoined)
+
+	//there is no game to wait for, so the room does not use the drain window
+	cancel()
+	expectShutdown(t, seat)
+	if code := <-closeReq; code != "IDLE1" {
+		t.Errorf("expected the room to ask to be closed, got %q", code)
+	}
+}
+
+func TestRoomDrainLetsGameFinish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan Result, 1)
+	_, closeReq, seats, _ := startRunningRoomUntil(t, ctx, Options{DrainWindow: time.Minute, OnResult: func(result Result) { results <- result }})
+
+	cancel()
+	seats[0].PlayerToRoom <- messages.ClientMessage{Type: messages.ClientConcede}
+	for _, seat := range seats {
+		expectMessage(t, seat, messages.ServerGameFinished)
+		//no rematch once the game is over
+		expectShutdown(t, seat)
+	}
+	<-closeReq
+	select {
+	case <-results:
+	default:
+		t.Error("a game finished while draining should still be reported")
+	}
+}
+
+func TestRoomDrainEndsUnfinishedGame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan Result, 1)
+	_, closeReq, seats, _ := startRunningRoomUntil(t, ctx, Options{DrainWindow: 10 * time.Millisecond, OnResult: func(result Result) { results <- result }})
+
+	cancel()
+	for _, seat := range seats {
+		expectShutdown(t, seat)
+	}
+	<-closeReq
+	select {
+	case result := <-results:
+		t.Errorf("a game cut short by the shutdown should not be reported, got %+v", result)
+	default:
+	}
+}
@@ -0,0 +1,61 @@
+package gameroom
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+var ErrShuttingDown = errors.New("the server is shutting down")
+
+// drain - Starts winding the room down once the server is shutting down. A game in progress gets
+// the drain window to finish, and the room closes as soon as there is no game running.
+func (room *Room) drain() {
+	room.draining = true
+	if _, running := room.state.(RoomStateRunning); running {
+		log.Printf("Room %v draining, the game has %v to finish", room.code, room.options.DrainWindow)
+	}
+	room.drainTimer = time.NewTimer(room.options.DrainWindow)
+}
+
+func (room *Room) drainExpired() <-chan time.Time {
+	if room.drainTimer == nil {
+		return nil
+	}
+	return room.drainTimer.C
+}
+
+// drained - Whether a draining room has nothing left worth keeping it open for.
+func (room *Room) drained() bool {
+	_, running := room.state.(RoomStateRunning)
+	return room.draining && !running
+}
+
+// closeForShutdown - Closes the room and tells everyone in it why. A game cut short by the server
+// is nobody's fault, so it is not reported and counts for neither player.
+func (room *Room) closeForShutdown() {
+	room.stopClock()
+	room.drainTimer.Stop()
+	closed := messages.ServerMessage{
+		Type:         messages.ServerRoomClosed,
+		Game:         messages.NewGameWrapper(room.game),
+		ErrorMessage: ErrShuttingDown.Error(),
+	}
+	room.stampMessage(&closed)
+
+	//like endGameOnQuit, the players may already be gone and must not hold up the shutdown
+	for playerNumber := 1; playerNumber <= 2; playerNumber++ {
+		seat := room.seat(playerNumber)
+		if *seat == (Chans{}) {
+			continue
+		}
+		select {
+		case seat.RoomToPlayer <- closed:
+		default:
+			log.Printf("Could not send message to player %v, channel unavailable", playerNumber)
+		}
+	}
+	room.sendToSpectators(closed)
+}
@@ -0,0 +1,35 @@
+package messages
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxChatLength - The most characters a chat message may hold, anything longer is cut off.
+const MaxChatLength = 200
+
+// escapeSequencePattern - Whole escape sequences, so none of their parameters are left behind as text.
+var escapeSequencePattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)?|\x1b[@-_]?`)
+
+// SanitizeChat - Cleans up a chat message so it is safe to print in another player's terminal.
+// Escape sequences and every other control character are removed, and runs of whitespace become
+// single spaces, so a message is always one line of plain text.
+func SanitizeChat(text string) string {
+	text = escapeSequencePattern.ReplaceAllString(text, "")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Bidi_Control, r), r == unicode.ReplacementChar:
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > MaxChatLength {
+		text = string(runes[:MaxChatLength])
+	}
+	return text
+}
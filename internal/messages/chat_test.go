@@ -0,0 +1,26 @@
+package messages
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeChat(t *testing.T) {
+	for _, test := range []struct{ text, expected string }{
+		{"  good   game!  ", "good game!"},
+		{"\x1b[2J\x1b[31mred\x1b[0m text", "red text"},
+		{"\x1b]0;new title\x07hello", "hello"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"bell\a and\r\nnew line\x00\u009b", "bell and new line"},
+		{"right‮to left", "rightto left"},
+		{"ünïcödé ♟ is fine", "ünïcödé ♟ is fine"},
+	} {
+		if sanitized := SanitizeChat(test.text); sanitized != test.expected {
+			t.Errorf("SanitizeChat(%q) = %q, expected %q", test.text, sanitized, test.expected)
+		}
+	}
+
+	if long := SanitizeChat(strings.Repeat("é", MaxChatLength+10)); len([]rune(long)) != MaxChatLength {
+		t.Errorf("expected long messages to be cut to %d characters, got %d", MaxChatLength, len([]rune(long)))
+	}
+}
@@ -0,0 +1,85 @@
+package messages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeControl - How long players get to move. Base is each player's starting time, and Increment is
+// added to it after each of their moves. PerMove instead gives a fixed time for every move, with
+// nothing carried over. The zero value is an untimed game. Room messages carry the time control
+// along with Clocks, each player's time left as the message was sent, with the player to move's
+// clock still running.
+type TimeControl struct {
+	Base      time.Duration `json:"base"`
+	Increment time.Duration `json:"increment"`
+	PerMove   time.Duration `json:"per_move"`
+}
+
+func (control TimeControl) IsTimed() bool {
+	return control.Base > 0 || control.PerMove > 0
+}
+
+func (control TimeControl) String() string {
+	switch {
+	case control.PerMove > 0:
+		return shortDuration(control.PerMove) + " per move"
+	case control.Base > 0 && control.Increment > 0:
+		return shortDuration(control.Base) + " + " + shortDuration(control.Increment)
+	case control.Base > 0:
+		return shortDuration(control.Base)
+	default:
+		return "Untimed"
+	}
+}
+
+// shortDuration - Writes whole minutes as "5m" rather than Duration's "5m0s".
+func shortDuration(duration time.Duration) string {
+	text := duration.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// ParseTimeControl - Reads a time control written as "5m+3s" for base time plus increment, "10m"
+// for base time alone, or "30s/move" for a fixed time per move. An empty string or "none" is untimed.
+func ParseTimeControl(text string) (TimeControl, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text == "none" {
+		return TimeControl{}, nil
+	}
+
+	if perMove, ok := strings.CutSuffix(text, "/move"); ok {
+		duration, err := time.ParseDuration(perMove)
+		if err != nil || duration <= 0 {
+			return TimeControl{}, fmt.Errorf("bad time per move %q", perMove)
+		}
+		return TimeControl{PerMove: duration}, nil
+	}
+
+	base, increment, hasIncrement := strings.Cut(text, "+")
+	control := TimeControl{}
+	var err error
+	if control.Base, err = time.ParseDuration(base); err != nil || control.Base <= 0 {
+		return TimeControl{}, fmt.Errorf("bad base time %q", base)
+	}
+	if hasIncrement {
+		if control.Increment, err = time.ParseDuration(increment); err != nil || control.Increment < 0 {
+			return TimeControl{}, fmt.Errorf("bad increment %q", increment)
+		}
+	}
+	return control, nil
+}
+
+// StartingClock - The time each player has on the clock when a game starts.
+func (control TimeControl) StartingClock() time.Duration {
+	if control.PerMove > 0 {
+		return control.PerMove
+	}
+	return control.Base
+}
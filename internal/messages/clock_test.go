@@ -0,0 +1,35 @@
+package messages
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected TimeControl
+		name     string
+	}{
+		{text: "", expected: TimeControl{}, name: "Untimed"},
+		{text: "5m+3s", expected: TimeControl{Base: 5 * time.Minute, Increment: 3 * time.Second}, name: "5m + 3s"},
+		{text: "1h", expected: TimeControl{Base: time.Hour}, name: "1h"},
+		{text: "90s", expected: TimeControl{Base: 90 * time.Second}, name: "1m30s"},
+		{text: "30s/move", expected: TimeControl{PerMove: 30 * time.Second}, name: "30s per move"},
+	}
+	for _, test := range tests {
+		control, err := ParseTimeControl(test.text)
+		if err != nil || control != test.expected {
+			t.Errorf("%q: expected %+v, got %+v (%v)", test.text, test.expected, control, err)
+		}
+		if control.String() != test.name {
+			t.Errorf("%q: expected it to read %q, got %q", test.text, test.name, control.String())
+		}
+	}
+
+	for _, text := range []string{"5", "0s", "5m+", "-1m", "0s/move"} {
+		if _, err := ParseTimeControl(text); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
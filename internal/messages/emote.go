@@ -0,0 +1,36 @@
+package messages
+
+// Emote - One of the fixed reactions players can send during a game. Being a fixed set, they are
+// safe to show to strangers in a way free text is not.
+type Emote int
+
+const (
+	EmoteGoodGame Emote = iota
+	EmoteNiceMove
+	EmoteHurryUp
+	EmoteOops
+)
+
+// Emotes - Every emote, in the order of the number keys that send them.
+func Emotes() []Emote {
+	return []Emote{EmoteGoodGame, EmoteNiceMove, EmoteHurryUp, EmoteOops}
+}
+
+func (emote Emote) IsValid() bool {
+	return emote >= EmoteGoodGame && emote <= EmoteOops
+}
+
+func (emote Emote) String() string {
+	switch emote {
+	case EmoteGoodGame:
+		return "gg"
+	case EmoteNiceMove:
+		return "nice move"
+	case EmoteHurryUp:
+		return "hurry up"
+	case EmoteOops:
+		return "oops"
+	default:
+		return "Unknown"
+	}
+}
@@ -1,6 +1,10 @@
 package messages
 
-import "github.com/wbarthol/ascii-arcade-2/internal/game"
+import (
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
 
 type GameResult int
 
@@ -27,11 +31,14 @@ func (wrapper *GameWrapper) GetGame() game.Game {
 	}
 }
 
+// NewGameWrapper - Wraps a snapshot of the game, so the message stays unchanged if the room
+// goes on to mutate its game before the message is serialized or read in-process.
 func NewGameWrapper(g game.Game) GameWrapper {
 	if g == nil {
 		return GameWrapper{}
 	}
 
+	g = g.Clone()
 	gameWrapper := GameWrapper{Type: g.GetGameType()}
 
 	switch g.GetGameType() {
@@ -56,6 +63,25 @@ const (
 	ServerRoomClosed
 	ServerRoomUnavailable
 	ServerError
+	ServerRematchVote
+	ServerResumed
+	ServerResumeFailed
+	ServerOpponentDisconnected
+	ServerOpponentReconnected
+	ServerSpectating
+	ServerRoomList
+	ServerSearchCancelled
+	ServerPasswordRequired
+	ServerLoggedIn
+	ServerAuthFailed
+	ServerLeaderboard
+	ServerGameHistory
+	ServerGameRecord
+	ServerOffer
+	ServerOfferAnswered
+	ServerChat
+	ServerEmote
+	ServerGoingAway
 )
 
 func (sType ServerMessageType) String() string {
@@ -78,19 +104,78 @@ func (sType ServerMessageType) String() string {
 		return "Room Unavailable"
 	case ServerError:
 		return "Error"
+	case ServerRematchVote:
+		return "Rematch Vote"
+	case ServerResumed:
+		return "Resumed"
+	case ServerResumeFailed:
+		return "Resume Failed"
+	case ServerOpponentDisconnected:
+		return "Opponent Disconnected"
+	case ServerOpponentReconnected:
+		return "Opponent Reconnected"
+	case ServerSpectating:
+		return "Spectating"
+	case ServerRoomList:
+		return "Room List"
+	case ServerSearchCancelled:
+		return "Search Cancelled"
+	case ServerPasswordRequired:
+		return "Password Required"
+	case ServerLoggedIn:
+		return "Logged In"
+	case ServerAuthFailed:
+		return "Auth Failed"
+	case ServerLeaderboard:
+		return "Leaderboard"
+	case ServerGameHistory:
+		return "Game History"
+	case ServerGameRecord:
+		return "Game Record"
+	case ServerOffer:
+		return "Offer"
+	case ServerOfferAnswered:
+		return "Offer Answered"
+	case ServerChat:
+		return "Chat"
+	case ServerEmote:
+		return "Emote"
+	case ServerGoingAway:
+		return "Going Away"
 	default:
 		return "Unknown"
 	}
 }
 
 type ServerMessage struct {
-	Type              ServerMessageType `json:"type"`
-	PlayerNumber      int               `json:"player_number"`
-	PlayerTurn        int               `json:"player_turn"`
-	Game              GameWrapper       `json:"game"`
-	GameResult        GameResult        `json:"game_result"`
-	QuittingPlayerNum int               `json:"quitting_player_num"`
-	ErrorMessage      string            `json:"error_message"`
+	Type              ServerMessageType  `json:"type"`
+	PlayerNumber      int                `json:"player_number"`
+	PlayerTurn        int                `json:"player_turn"`
+	Game              GameWrapper        `json:"game"`
+	GameResult        GameResult         `json:"game_result"`
+	QuittingPlayerNum int                `json:"quitting_player_num"`
+	ErrorMessage      string             `json:"error_message"`
+	Rematch           RematchOption      `json:"rematch"`
+	ResumeToken       string             `json:"resume_token"`
+	Phase             RoomPhase          `json:"phase"`
+	Rooms             []RoomInfo         `json:"rooms"`
+	RoomCode          string             `json:"room_code"`
+	PlayerNames       [2]string          `json:"player_names"`
+	Account           AccountInfo        `json:"account"`
+	SessionToken      string             `json:"session_token"`
+	Leaderboard       []LeaderboardEntry `json:"leaderboard"`
+	GameType          game.GameType      `json:"game_type"`
+	Records           []GameRecord       `json:"records"`
+	Record            GameRecord         `json:"record"`
+	TimeControl       TimeControl        `json:"time_control"`
+	Clocks            [2]time.Duration   `json:"clocks"`
+	TimedOutPlayerNum int                `json:"timed_out_player_num"`
+	Offer             Offer              `json:"offer"`
+	Accepted          bool               `json:"accepted"`
+	Text              string             `json:"text"`
+	Emote             Emote              `json:"emote"`
+	//DrainWindow is how long games in progress have left when the server starts shutting down
+	DrainWindow time.Duration `json:"drain_window"`
 }
 
 type GameTurnWrapper struct {
@@ -127,6 +212,106 @@ func NewGameTurnWrapper(g game.GameTurn) GameTurnWrapper {
 	return gameWrapper
 }
 
+// RematchOption - What a player votes for once a game is over. The rematch starts when both votes match.
+type RematchOption int
+
+const (
+	RematchSameSides RematchOption = iota
+	RematchSwapSides
+	RematchNewGame
+)
+
+func (option RematchOption) String() string {
+	switch option {
+	case RematchSameSides:
+		return "Rematch"
+	case RematchSwapSides:
+		return "Rematch with sides swapped"
+	case RematchNewGame:
+		return "Pick a different game"
+	default:
+		return "Unknown"
+	}
+}
+
+// Offer - Something a player asks their opponent to agree to during a game. A takeback undoes the
+// asking player's last move, along with the opponent's reply if they have made one. An offer is
+// withdrawn as soon as either player moves.
+type Offer int
+
+const (
+	OfferDraw Offer = iota
+	OfferTakeback
+)
+
+func (offer Offer) String() string {
+	switch offer {
+	case OfferDraw:
+		return "Draw"
+	case OfferTakeback:
+		return "Takeback"
+	default:
+		return "Unknown"
+	}
+}
+
+// RoomPhase - Where a room is at, sent with a resync so a reconnecting client knows which screen to show.
+type RoomPhase int
+
+const (
+	RoomPhaseWaitingForOpponent RoomPhase = iota
+	RoomPhaseGameSelection
+	RoomPhaseInGame
+	RoomPhasePostGame
+)
+
+func (phase RoomPhase) String() string {
+	switch phase {
+	case RoomPhaseWaitingForOpponent:
+		return "Waiting for opponent"
+	case RoomPhaseGameSelection:
+		return "Choosing a game"
+	case RoomPhaseInGame:
+		return "In game"
+	case RoomPhasePostGame:
+		return "Game over"
+	default:
+		return "Unknown"
+	}
+}
+
+// RoomInfo - How a public room appears in the lobby.
+type RoomInfo struct {
+	Code        string        `json:"code"`
+	GameType    game.GameType `json:"game_type"`
+	Players     int           `json:"players"`
+	Spectators  int           `json:"spectators"`
+	Phase       RoomPhase     `json:"phase"`
+	Locked      bool          `json:"locked"`
+	Rated       bool          `json:"rated"`
+	TimeControl TimeControl   `json:"time_control"`
+}
+
+// PlayerStats - A player's record at one game type. Rating is zero until their first rated game.
+type PlayerStats struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+	Rating int `json:"rating"`
+}
+
+// LeaderboardEntry - One rated player's standing at the game type the leaderboard was asked for.
+type LeaderboardEntry struct {
+	Username string      `json:"username"`
+	Stats    PlayerStats `json:"stats"`
+}
+
+// AccountInfo - What a logged in player is told about their own account.
+type AccountInfo struct {
+	Username string                        `json:"username"`
+	Stats    map[game.GameType]PlayerStats `json:"stats"`
+}
+
 type ClientMessageType int
 
 const (
@@ -135,11 +320,51 @@ const (
 	ClientSendTurn
 	ClientQuitRoom
 	ClientConcede
+	ClientRematchVote
+	ClientResume
+	//ClientDisconnected is sent to the room by the server when a connection drops, never by a client
+	ClientDisconnected
+	ClientListRooms
+	ClientQuickMatch
+	ClientCancelQuickMatch
+	ClientCreateRoom
+	ClientRegister
+	ClientLogin
+	ClientLogout
+	ClientLeaderboard
+	ClientGameHistory
+	ClientFetchGame
+	ClientMakeOffer
+	ClientAnswerOffer
+	ClientChat
+	ClientEmote
 )
 
+// ClientMessage - Public lists a room in the lobby, Rated has it move the players' ratings and
+// TimeControl puts clocks on its games, all only counting when creating a room. Password
+// locks a room when creating it, and unlocks it when joining. When registering or logging in it is
+// the account's password instead. SessionToken, from an earlier login, logs in without one. Limit
+// is how many games to list when asking for a game history, and GameID the game to fetch. Offer is
+// what is being offered or answered during a game, and Accept the answer. Text is a chat message,
+// and Emote the reaction to send.
 type ClientMessage struct {
-	Type       ClientMessageType `json:"type"`
-	RoomCode   string            `json:"room_code"`
-	GameType   game.GameType     `json:"game_type"`
-	TurnAction GameTurnWrapper   `json:"turn_action"`
+	Type         ClientMessageType `json:"type"`
+	RoomCode     string            `json:"room_code"`
+	GameType     game.GameType     `json:"game_type"`
+	TurnAction   GameTurnWrapper   `json:"turn_action"`
+	Rematch      RematchOption     `json:"rematch"`
+	ResumeToken  string            `json:"resume_token"`
+	Public       bool              `json:"public"`
+	Rated        bool              `json:"rated"`
+	Password     string            `json:"password"`
+	Nickname     string            `json:"nickname"`
+	Username     string            `json:"username"`
+	SessionToken string            `json:"session_token"`
+	Limit        int               `json:"limit"`
+	GameID       string            `json:"game_id"`
+	TimeControl  TimeControl       `json:"time_control"`
+	Offer        Offer             `json:"offer"`
+	Accept       bool              `json:"accept"`
+	Text         string            `json:"text"`
+	Emote        Emote             `json:"emote"`
 }
@@ -0,0 +1,77 @@
+package messages
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+// GameRecord - Everything needed to replay a finished game: who played it, every turn in the order
+// it was accepted, and how it ended. Players and Accounts are per seat, indexed by player number - 1.
+type GameRecord struct {
+	ID       string        `json:"id"`
+	GameType game.GameType `json:"game_type"`
+	Rated    bool          `json:"rated"`
+	//a checkers FEN for games that did not start from the usual position
+	Setup string `json:"setup,omitempty"`
+	//nicknames as shown in the room, accounts are empty for guests
+	Players  [2]string      `json:"players"`
+	Accounts [2]string      `json:"accounts"`
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Turns    []RecordedTurn `json:"turns"`
+	Results  [2]GameResult  `json:"results"`
+	//set when the game ended because a player left rather than on the board
+	QuittingPlayerNum int `json:"quitting_player_num"`
+	//set when the game ended because a player ran out of time
+	TimedOutPlayerNum int `json:"timed_out_player_num,omitempty"`
+}
+
+// RecordedTurn - One accepted turn, exactly as the player sent it.
+type RecordedTurn struct {
+	PlayerNumber int             `json:"player_number"`
+	Turn         GameTurnWrapper `json:"turn"`
+	Time         time.Time       `json:"time"`
+}
+
+// Positions - Plays the record back from the start. The first position is the empty board, or the
+// setup if there is one, and every turn adds the position after it, so a record of n turns gives
+// n + 1 positions. A turn that does not fit the game so far stops the replay there, with the
+// positions up to it still returned.
+func (record GameRecord) Positions() ([]game.Game, error) {
+	current := game.NewGame(record.GameType)
+	if current == nil {
+		return nil, fmt.Errorf("unknown game type %v", record.GameType)
+	}
+	if record.Setup != "" {
+		if record.GameType != game.GameTypeCheckers {
+			return nil, fmt.Errorf("a %v game cannot start from a set up position", record.GameType)
+		}
+		setup, _, err := game.ParseCheckersFEN(record.Setup)
+		if err != nil {
+			return nil, err
+		}
+		current = setup
+	}
+
+	positions := []game.Game{current.Clone()}
+	for i, recorded := range record.Turns {
+		turn := recorded.Turn.GetGameTurn()
+		if turn == nil || turn.GetGameType() != record.GameType {
+			return positions, fmt.Errorf("turn %d is not a %v turn", i+1, record.GameType)
+		}
+		if valid, reason := current.ValidateMove(turn, recorded.PlayerNumber); !valid {
+			return positions, fmt.Errorf("turn %d is not valid: %v", i+1, reason)
+		}
+		current.ExecuteTurn(turn, recorded.PlayerNumber)
+		positions = append(positions, current.Clone())
+	}
+	return positions, nil
+}
+
+// HasResult - Whether the record says how the game ended. Both seats winning is the zero value and
+// never a real result, so it marks a game that was recorded unfinished, like an imported one.
+func (record GameRecord) HasResult() bool {
+	return record.Results != [2]GameResult{}
+}
@@ -0,0 +1,79 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func ticTacToeTurn(playerNumber, x, y int) RecordedTurn {
+	return RecordedTurn{
+		PlayerNumber: playerNumber,
+		Turn:         NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(x, y)}),
+	}
+}
+
+func TestRecordPositionsReplaysTurns(t *testing.T) {
+	record := GameRecord{
+		GameType: game.GameTypeTicTacToe,
+		Turns: []RecordedTurn{
+			ticTacToeTurn(1, 0, 0), ticTacToeTurn(2, 0, 1),
+			ticTacToeTurn(1, 1, 0), ticTacToeTurn(2, 1, 1),
+			ticTacToeTurn(1, 2, 0),
+		},
+	}
+
+	positions, err := record.Positions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != len(record.Turns)+1 {
+		t.Fatalf("expected %d positions, got %d", len(record.Turns)+1, len(positions))
+	}
+	if status := positions[len(positions)-2].GetGameStatus(); status != game.GameStatusOngoing {
+		t.Errorf("expected the game to still be going before the last turn, got %v", status)
+	}
+	if status := positions[len(positions)-1].GetGameStatus(); status != game.GameStatusPlayer1Win {
+		t.Errorf("expected player 1 to have won, got %v", status)
+	}
+}
+
+func TestRecordPositionsStopsAtInvalidTurn(t *testing.T) {
+	record := GameRecord{
+		GameType: game.GameTypeTicTacToe,
+		Turns:    []RecordedTurn{ticTacToeTurn(1, 1, 1), ticTacToeTurn(2, 1, 1)},
+	}
+
+	positions, err := record.Positions()
+	if err == nil {
+		t.Fatal("expected playing on an occupied square to fail")
+	}
+	if len(positions) != 2 {
+		t.Errorf("expected the positions before the bad turn, got %d", len(positions))
+	}
+}
+
+func TestRecordPositionsStartFromSetup(t *testing.T) {
+	record := GameRecord{
+		GameType: game.GameTypeCheckers,
+		Setup:    "B:W18:B14",
+		Turns: []RecordedTurn{{
+			PlayerNumber: 2,
+			Turn:         NewGameTurnWrapper(game.CheckersTurn{PieceCoords: vector.NewVector(5, 3), Direction: game.CheckersDirectionRight}),
+		}},
+	}
+
+	positions, err := record.Positions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := positions[len(positions)-1].GetGameStatus(); status != game.GameStatusPlayer2Win {
+		t.Errorf("expected black to win by taking the last piece, got %v", status)
+	}
+
+	record.GameType = game.GameTypeTicTacToe
+	if _, err := record.Positions(); err == nil {
+		t.Error("expected a set up position to be refused for tic tac toe")
+	}
+}
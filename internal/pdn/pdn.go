@@ -0,0 +1,336 @@
+// Package pdn reads and writes checkers games in Portable Draughts Notation, the format other
+// checkers programs and game collections use.
+package pdn
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	dateLayout = "2006.01.02"
+	//PDN's number for 8x8 checkers, also called English draughts
+	gameTypeTag = "21"
+	lineWidth   = 80
+)
+
+var ErrNotCheckers = errors.New("only checkers games can be written as PDN")
+
+var (
+	tagPattern        = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+	moveNumberPattern = regexp.MustCompile(`^\d+\.+`)
+	nagPattern        = regexp.MustCompile(`^\$\d+$`)
+)
+
+// Write - Turns a checkers record into a PDN game with its tags and numbered moves. Jumps the same
+// piece makes one after another are written together as one move, like "27x18x9". Only records
+// imported by Parse can have those, since games played here take one jump per turn.
+func Write(record messages.GameRecord) (string, error) {
+	if record.GameType != game.GameTypeCheckers {
+		return "", ErrNotCheckers
+	}
+	positions, err := record.Positions()
+	if err != nil {
+		return "", err
+	}
+
+	moves := []string{}
+	movers := []int{}
+	//landing is where the last jump ended, so a jump from there adds on to it
+	landing := 0
+	for i, recorded := range record.Turns {
+		turn := recorded.Turn.GetGameTurn().(game.CheckersTurn)
+		notation, err := positions[i].(*game.CheckersGame).TurnNotation(turn, recorded.PlayerNumber)
+		if err != nil {
+			return "", fmt.Errorf("turn %d: %w", i+1, err)
+		}
+
+		from, to, isCapture := splitMove(notation)
+		last := len(moves) - 1
+		if isCapture && last >= 0 && movers[last] == recorded.PlayerNumber && landing == from {
+			moves[last] += "x" + strconv.Itoa(to)
+		} else {
+			moves = append(moves, notation)
+			movers = append(movers, recorded.PlayerNumber)
+		}
+		landing = 0
+		if isCapture {
+			landing = to
+		}
+	}
+
+	firstMover := 1
+	switch {
+	case len(movers) > 0:
+		firstMover = movers[0]
+	case record.Setup != "":
+		_, firstMover, _ = game.ParseCheckersFEN(record.Setup)
+	}
+	fen := record.Setup
+	if fen == "" && firstMover == 1 {
+		//black moves first in standard checkers, so a game where white starts needs its position spelled out
+		fen = positions[0].(*game.CheckersGame).FEN(firstMover)
+	}
+
+	var builder strings.Builder
+	writeTag(&builder, "Event", event(record))
+	date := "????.??.??"
+	if !record.Started.IsZero() {
+		date = record.Started.Format(dateLayout)
+	}
+	writeTag(&builder, "Date", date)
+	writeTag(&builder, "White", playerName(record.Players[0]))
+	writeTag(&builder, "Black", playerName(record.Players[1]))
+	writeTag(&builder, "Result", resultToken(record))
+	writeTag(&builder, "GameType", gameTypeTag)
+	if fen != "" {
+		writeTag(&builder, "FEN", fen)
+	}
+	builder.WriteString("\n")
+	builder.WriteString(movetext(moves, movers, resultToken(record)))
+	builder.WriteString("\n")
+	return builder.String(), nil
+}
+
+func splitMove(notation string) (int, int, bool) {
+	separator := "-"
+	if strings.Contains(notation, "x") {
+		separator = "x"
+	}
+	first, last, _ := strings.Cut(notation, separator)
+	from, _ := strconv.Atoi(first)
+	to, _ := strconv.Atoi(last)
+	return from, to, separator == "x"
+}
+
+func event(record messages.GameRecord) string {
+	if record.Rated {
+		return "ASCII Arcade rated game"
+	}
+	return "ASCII Arcade game"
+}
+
+func playerName(name string) string {
+	if name == "" {
+		return "?"
+	}
+	return name
+}
+
+func writeTag(builder *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(builder, "[%v \"%v\"]\n", name, value)
+}
+
+// resultToken - The result as PDN writes it, with white's score first.
+func resultToken(record messages.GameRecord) string {
+	if !record.HasResult() {
+		return "*"
+	}
+	switch record.Results[0] {
+	case messages.GameResultPlayerWin:
+		return "1-0"
+	case messages.GameResultPlayerLose:
+		return "0-1"
+	default:
+		return "1/2-1/2"
+	}
+}
+
+// movetext - Numbers the moves, where each number covers black's move and then white's.
+func movetext(moves []string, movers []int, result string) string {
+	tokens := []string{}
+	number := 1
+	for i, move := range moves {
+		switch {
+		case movers[i] == 2:
+			tokens = append(tokens, fmt.Sprintf("%d.", number))
+		case i == 0:
+			tokens = append(tokens, fmt.Sprintf("%d...", number))
+		}
+		tokens = append(tokens, move)
+		if movers[i] == 1 {
+			number++
+		}
+	}
+	tokens = append(tokens, result)
+
+	lines := []string{}
+	line := ""
+	for _, token := range tokens {
+		if line != "" && len(line)+1+len(token) > lineWidth {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += token
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// Parse - Reads the first game in a PDN file. Comments, variations and move annotations are skipped.
+// Without a FEN tag the game starts from the usual position with black to move. A multiple capture
+// becomes one turn per jump by the same player, which the replay viewer steps through but a live
+// game could never produce, so the record is for replaying only.
+func Parse(text string) (messages.GameRecord, error) {
+	tags, body, err := splitGame(text)
+	if err != nil {
+		return messages.GameRecord{}, err
+	}
+	if gameType, ok := tags["GameType"]; ok && !strings.HasPrefix(gameType, gameTypeTag) {
+		return messages.GameRecord{}, fmt.Errorf("PDN game type %v is not 8x8 checkers", gameType)
+	}
+
+	record := messages.GameRecord{
+		GameType: game.GameTypeCheckers,
+		Players:  [2]string{knownValue(tags["White"]), knownValue(tags["Black"])},
+		Setup:    tags["FEN"],
+	}
+	if date, err := time.Parse(dateLayout, tags["Date"]); err == nil {
+		record.Started = date
+	}
+
+	board := game.NewCheckersGame()
+	playerToMove := 2
+	if record.Setup != "" {
+		if board, playerToMove, err = game.ParseCheckersFEN(record.Setup); err != nil {
+			return messages.GameRecord{}, err
+		}
+	}
+
+	result := tags["Result"]
+	tokens, err := movetextTokens(body)
+	if err != nil {
+		return messages.GameRecord{}, err
+	}
+	for _, token := range tokens {
+		if isResult(token) {
+			result = token
+			break
+		}
+		turns, err := board.ParseMove(token, playerToMove)
+		if err != nil {
+			return messages.GameRecord{}, fmt.Errorf("move %v: %w", token, err)
+		}
+		for _, turn := range turns {
+			board.ExecuteTurn(turn, playerToMove)
+			record.Turns = append(record.Turns, messages.RecordedTurn{
+				PlayerNumber: playerToMove,
+				Turn:         messages.NewGameTurnWrapper(turn),
+			})
+		}
+		playerToMove = 3 - playerToMove
+	}
+
+	switch result {
+	case "1-0", "2-0":
+		record.Results = [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose}
+	case "0-1", "0-2":
+		record.Results = [2]messages.GameResult{messages.GameResultPlayerLose, messages.GameResultPlayerWin}
+	case "1/2-1/2", "1-1":
+		record.Results = [2]messages.GameResult{messages.GameResultDraw, messages.GameResultDraw}
+	}
+	if record.HasResult() {
+		record.Finished = record.Started
+	}
+	return record, nil
+}
+
+// splitGame - Separates the first game's tags from its movetext.
+func splitGame(text string) (map[string]string, string, error) {
+	tags := map[string]string{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") {
+			break
+		}
+		match := tagPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, "", fmt.Errorf("bad PDN tag %v", line)
+		}
+		value := strings.ReplaceAll(match[2], `\"`, `"`)
+		tags[match[1]] = strings.ReplaceAll(value, `\\`, `\`)
+	}
+
+	body := []string{}
+	for ; i < len(lines); i++ {
+		//a tag after the moves belongs to the next game
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
+			break
+		}
+		body = append(body, lines[i])
+	}
+	if len(tags) == 0 && strings.TrimSpace(strings.Join(body, "")) == "" {
+		return nil, "", errors.New("no PDN game found")
+	}
+	return tags, strings.Join(body, "\n"), nil
+}
+
+// movetextTokens - The moves and result in the movetext, with everything else taken out.
+func movetextTokens(body string) ([]string, error) {
+	//comments and variations are dropped first, since they can hold anything
+	var plain strings.Builder
+	comment, variation := false, 0
+	for _, r := range body {
+		switch {
+		case comment:
+			comment = r != '}'
+		case r == '{':
+			comment = true
+		case r == '(':
+			variation++
+		case r == ')' && variation > 0:
+			variation--
+		case variation > 0:
+		default:
+			plain.WriteRune(r)
+		}
+	}
+	if comment || variation > 0 {
+		return nil, errors.New("unclosed comment or variation in PDN moves")
+	}
+
+	tokens := []string{}
+	for _, field := range strings.Fields(plain.String()) {
+		if field != "*" {
+			field = moveNumberPattern.ReplaceAllString(field, "")
+			field = strings.TrimRight(field, "!?")
+		}
+		if field == "" || nagPattern.MatchString(field) {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens, nil
+}
+
+func isResult(token string) bool {
+	switch token {
+	case "1-0", "0-1", "1/2-1/2", "2-0", "0-2", "1-1", "*":
+		return true
+	}
+	return false
+}
+
+// knownValue - PDN writes "?" for a tag nobody knows.
+func knownValue(value string) string {
+	if value == "?" {
+		return ""
+	}
+	return value
+}
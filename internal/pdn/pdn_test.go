@@ -0,0 +1,145 @@
+package pdn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const sampleGame = `[Event "Club night"]
+[Date "2024.03.09"]
+[White "Ada"]
+[Black "Grace"]
+[Result "0-1"]
+[GameType "21"]
+
+1. 11-15 {the Old Fourteenth starts here} 23-19 2. 8-11! 22-17 (2... 22-18 15x22) 3. 9-13 $1
+17-14 4. 10x17 21x14 0-1
+
+[Event "Second game"]
+1. 9-14 *
+`
+
+func TestParseReadsFirstGame(t *testing.T) {
+	record, err := Parse(sampleGame)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.GameType != game.GameTypeCheckers || record.Players != [2]string{"Ada", "Grace"} {
+		t.Errorf("unexpected game or players: %v %v", record.GameType, record.Players)
+	}
+	if !record.Started.Equal(time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the date to be read, got %v", record.Started)
+	}
+	expected := [2]messages.GameResult{messages.GameResultPlayerLose, messages.GameResultPlayerWin}
+	if record.Results != expected {
+		t.Errorf("expected black to have won, got %v", record.Results)
+	}
+
+	//black moves first in standard checkers
+	if len(record.Turns) != 8 || record.Turns[0].PlayerNumber != 2 || record.Turns[1].PlayerNumber != 1 {
+		t.Fatalf("expected 8 turns starting with black, got %+v", record.Turns)
+	}
+	positions, err := record.Positions()
+	if err != nil {
+		t.Fatalf("expected the parsed game to replay, got %v", err)
+	}
+	final := positions[len(positions)-1].(*game.CheckersGame)
+	if fen := final.FEN(1); fen != "W:W14,19,24,25,26,27,28,29,30,31,32:B1,2,3,4,5,6,7,11,12,13,15" {
+		t.Errorf("unexpected final position %v", fen)
+	}
+}
+
+func TestWriteThenParse(t *testing.T) {
+	if _, err := Parse("[FEN \"W:W21-32:B1-12\"]\n1... 24-19 2. 9-14 24-20 *"); err == nil {
+		t.Fatal("expected moving a piece that is no longer there to fail")
+	}
+
+	record, err := Parse("[FEN \"W:W21-32:B1-12\"]\n1... 24-19 2. 9-14 23-18 14x23 27x18 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.Players = [2]string{"Ada", ""}
+	record.Started = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	record.Results = [2]messages.GameResult{messages.GameResultDraw, messages.GameResultDraw}
+
+	text, err := Write(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`[Date "2026.10.19"]`, `[White "Ada"]`, `[Black "?"]`, `[Result "1/2-1/2"]`, `[FEN "W:W21-32:B1-12"]`,
+		"1... 24-19 2. 9-14 23-18 3. 14x23 27x18 1/2-1/2",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%v", expected, text)
+		}
+	}
+
+	again, err := Parse(text)
+	if err != nil {
+		t.Fatalf("expected the written game to parse, got %v", err)
+	}
+	if len(again.Turns) != len(record.Turns) || again.Results != record.Results || again.Players != record.Players {
+		t.Errorf("game changed on the way through PDN: %+v", again)
+	}
+
+	//the arcade's own games start from the usual position with white to move, which needs a FEN tag
+	record.Setup = ""
+	if text, _ = Write(record); !strings.Contains(text, `[FEN "W:W21,22,23,24,25,26,27,28,29,30,31,32:B1,2,3,4,5,6,7,8,9,10,11,12"]`) {
+		t.Errorf("expected white moving first to be spelled out, got:\n%v", text)
+	}
+}
+
+func TestWriteJoinsMultipleCaptures(t *testing.T) {
+	record, err := Parse("[FEN \"W:W27:B23,14\"]\n1... 27x18x9 1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Turns) != 2 {
+		t.Fatalf("expected a turn for each jump, got %d", len(record.Turns))
+	}
+
+	text, err := Write(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "1... 27x18x9 1-0") {
+		t.Errorf("expected the jumps to be written as one move, got:\n%v", text)
+	}
+}
+
+func TestParsedMultipleCaptureReplays(t *testing.T) {
+	record, err := Parse("[FEN \"W:W27:B23,14\"]\n1... 27x18x9 1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Turns[0].PlayerNumber != 1 || record.Turns[1].PlayerNumber != 1 {
+		t.Fatalf("expected white to make both jumps, got %+v", record.Turns)
+	}
+
+	positions, err := record.Positions()
+	if err != nil {
+		t.Fatalf("expected the jumps to replay, got %v", err)
+	}
+	if len(positions) != 3 {
+		t.Fatalf("expected a position before and after each jump, got %d", len(positions))
+	}
+	if fen := positions[2].(*game.CheckersGame).FEN(2); fen != "B:W9:B" {
+		t.Errorf("expected white alone on 9 after both captures, got %v", fen)
+	}
+}
+
+func TestWriteRejectsOtherGames(t *testing.T) {
+	if _, err := Write(messages.GameRecord{GameType: game.GameTypeTicTacToe}); !errors.Is(err, ErrNotCheckers) {
+		t.Errorf("expected tic-tac-toe to be refused, got %v", err)
+	}
+	if _, err := Parse("[GameType \"20\"]\n1. 32-28 *"); err == nil {
+		t.Error("expected international draughts to be refused")
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/ai"
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	localRoomCode = "LOCAL"
+	//room sends to players are sometimes non-blocking, so seats need room to buffer
+	localSeatBuffer = 16
+)
+
+// LocalDriver - Runs the same room logic as the server inside this process, so games work with no
+// network at all. The session takes seat one, and seat two is either a bot or, in hot seat mode,
+// a second person sharing the keyboard.
+type LocalDriver struct {
+	difficulty ai.Difficulty
+	hotSeat    bool
+	//a checkers FEN every game starts from, which skips game selection
+	setup      string
+	playerTurn int
+	//seat one's player number, which changes when a rematch swaps sides
+	seatNumber int
+
+	room     *gameroom.Room
+	seat     gameroom.Chans
+	seatTwo  gameroom.Chans
+	roomDone chan struct{}
+	closeReq chan string
+
+	sessionToDriver chan messages.ClientMessage
+	driverToSession chan messages.ServerMessage
+	done            chan struct{}
+	closeOnce       sync.Once
+}
+
+func NewLocalDriver(session *Session, difficulty ai.Difficulty) *LocalDriver {
+	return &LocalDriver{
+		difficulty:      difficulty,
+		closeReq:        make(chan string, 1),
+		sessionToDriver: make(chan messages.ClientMessage),
+		driverToSession: session.driverToSession,
+		done:            make(chan struct{}),
+	}
+}
+
+// NewHotSeatDriver - A local driver where both seats are played from this session. Client messages
+// are routed to whichever seat's turn it is.
+func NewHotSeatDriver(session *Session) *LocalDriver {
+	driver := NewLocalDriver(session, ai.DifficultyEasy)
+	driver.hotSeat = true
+	return driver
+}
+
+func (driver *LocalDriver) WriteToServer(msg messages.ClientMessage) error {
+	select {
+	case driver.sessionToDriver <- msg:
+		return nil
+	case <-driver.done:
+		return errors.New("local game has been closed")
+	}
+}
+
+// Run - Plays the part of the server's Player for seat one: it relays client messages to the room
+// and room messages back to the session. Run is the only sender on driverToSession, so it is also
+// the one to close it.
+func (driver *LocalDriver) Run() {
+	defer close(driver.driverToSession)
+	defer driver.closeRoom()
+
+	for {
+		//a finished room must be cleared before handling a message that may need a new one
+		select {
+		case <-driver.closeReq:
+			driver.roomClosed()
+		default:
+		}
+
+		select {
+		case msg := <-driver.sessionToDriver:
+			driver.handleClientMessage(msg)
+		case msg := <-driver.seat.RoomToPlayer:
+			switch msg.Type {
+			case messages.ServerRoomJoined, messages.ServerGameStarted:
+				driver.seatNumber = msg.PlayerNumber
+			}
+			if msg.Type == messages.ServerGameStarted || msg.Type == messages.ServerTurnResult {
+				driver.playerTurn = msg.PlayerTurn
+			}
+			driver.send(msg)
+		case msg := <-driver.seatTwo.RoomToPlayer:
+			//seat one already receives everything shared, only errors are meant for seat two alone
+			if msg.Type == messages.ServerError {
+				driver.send(msg)
+			}
+		case <-driver.closeReq:
+			driver.roomClosed()
+		case <-driver.done:
+			return
+		}
+	}
+}
+
+func (driver *LocalDriver) handleClientMessage(msg messages.ClientMessage) {
+	if msg.Type == messages.ClientJoinRoom && driver.room == nil {
+		driver.openRoom(msg.Nickname)
+		return
+	}
+
+	if driver.room == nil {
+		//mirrors the server, which confirms a quit even when the player is not in a room
+		if msg.Type == messages.ClientQuitRoom {
+			driver.send(messages.ServerMessage{Type: messages.ServerRoomClosed})
+		}
+		return
+	}
+
+	driver.sendToSeat(driver.seatFor(msg), msg)
+	if driver.hotSeat && msg.Type == messages.ClientRematchVote && driver.room != nil {
+		//one key press at the shared keyboard answers for both players
+		driver.sendToSeat(driver.seatTwo, msg)
+	}
+}
+
+func (driver *LocalDriver) sendToSeat(seat gameroom.Chans, msg messages.ClientMessage) {
+	select {
+	case seat.PlayerToRoom <- msg:
+	case <-driver.closeReq:
+		driver.roomClosed()
+	}
+}
+
+// seatFor - In hot seat mode, turns and concessions come from whoever's turn it is. Everything
+// else, like selecting a game or quitting, is done as player one.
+func (driver *LocalDriver) seatFor(msg messages.ClientMessage) gameroom.Chans {
+	isTurnAction := msg.Type == messages.ClientSendTurn || msg.Type == messages.ClientConcede
+	if driver.hotSeat && isTurnAction && driver.playerTurn != driver.seatNumber {
+		return driver.seatTwo
+	}
+	return driver.seat
+}
+
+func (driver *LocalDriver) send(msg messages.ServerMessage) {
+	select {
+	case driver.driverToSession <- msg:
+	case <-driver.done:
+	}
+}
+
+// openRoom - Starts a fresh room, seats the session as player one and fills seat two.
+func (driver *LocalDriver) openRoom(nickname string) {
+	options := gameroom.Options{}
+	if driver.setup != "" {
+		options = gameroom.Options{AutoStart: true, GameType: game.GameTypeCheckers, Setup: driver.setup}
+	}
+	driver.room = gameroom.NewRoom(localRoomCode, driver.closeReq, options)
+	driver.roomDone = make(chan struct{})
+	driver.seat = newLocalSeat()
+	secondSeat := newLocalSeat()
+
+	go driver.room.Run(context.Background())
+	secondNickname := fmt.Sprintf("Computer (%v)", driver.difficulty)
+	if driver.hotSeat {
+		driver.seatTwo = secondSeat
+		//both players share this terminal, so the seats keep their numbered names
+		nickname, secondNickname = "", ""
+	} else {
+		go runBotSeat(secondSeat, driver.difficulty, driver.roomDone)
+	}
+
+	driver.room.Join(gameroom.Request{Code: localRoomCode, Chans: driver.seat, Nickname: nickname})
+	driver.room.Join(gameroom.Request{Code: localRoomCode, Chans: secondSeat, Nickname: secondNickname})
+}
+
+func (driver *LocalDriver) roomClosed() {
+	driver.room = nil
+	close(driver.roomDone)
+}
+
+// closeRoom - Quits a room that is still running so its goroutine does not outlive the driver.
+func (driver *LocalDriver) closeRoom() {
+	if driver.room == nil {
+		return
+	}
+	select {
+	case driver.seat.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientQuitRoom}:
+	case <-driver.closeReq:
+	}
+	close(driver.roomDone)
+}
+
+func (driver *LocalDriver) Close() {
+	if driver == nil {
+		return
+	}
+	driver.closeOnce.Do(func() {
+		close(driver.done)
+	})
+}
+
+func newLocalSeat() gameroom.Chans {
+	return gameroom.Chans{
+		RoomToPlayer: make(chan messages.ServerMessage, localSeatBuffer),
+		PlayerToRoom: make(chan messages.ClientMessage),
+	}
+}
+
+// runBotSeat - Plays a seat in a local room, answering every turn that is the bot's with a turn of its own.
+// After a game it agrees to whatever rematch the session votes for, and picks the next game if a
+// swap left it in the first seat. It lets the session take back moves, since practice is what it
+// is for, but never agrees to a draw.
+func runBotSeat(seat gameroom.Chans, difficulty ai.Difficulty, roomDone chan struct{}) {
+	playerNum := 0
+	var gameType game.GameType
+	for {
+		select {
+		case msg := <-seat.RoomToPlayer:
+			switch msg.Type {
+			case messages.ServerRoomJoined:
+				playerNum = msg.PlayerNumber
+			case messages.ServerRematchVote:
+				if msg.PlayerNumber == playerNum {
+					continue
+				}
+				select {
+				case seat.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientRematchVote, Rematch: msg.Rematch}:
+				case <-roomDone:
+					return
+				}
+			case messages.ServerEnteredGameSelection:
+				//only the first seat picks, and the session asked for a new game, so it gets a different one
+				if playerNum != 1 {
+					continue
+				}
+				gameTypes := game.GetGameTypes()
+				pick := gameTypes[(slices.Index(gameTypes, gameType)+1)%len(gameTypes)]
+				select {
+				case seat.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientSelectGameType, GameType: pick}:
+				case <-roomDone:
+					return
+				}
+			case messages.ServerOffer:
+				answer := messages.ClientMessage{
+					Type:   messages.ClientAnswerOffer,
+					Offer:  msg.Offer,
+					Accept: msg.Offer == messages.OfferTakeback,
+				}
+				select {
+				case seat.PlayerToRoom <- answer:
+				case <-roomDone:
+					return
+				}
+			case messages.ServerGameStarted, messages.ServerTurnResult:
+				if msg.Type == messages.ServerGameStarted {
+					playerNum = msg.PlayerNumber
+					gameType = msg.Game.GetGame().GetGameType()
+				}
+				if msg.PlayerTurn != playerNum {
+					continue
+				}
+
+				g := msg.Game.GetGame()
+				turn, err := ai.NewBot(g.GetGameType(), difficulty).SelectTurn(g, playerNum)
+				reply := messages.ClientMessage{Type: messages.ClientConcede}
+				if err == nil {
+					reply = messages.ClientMessage{
+						Type:       messages.ClientSendTurn,
+						TurnAction: messages.NewGameTurnWrapper(turn),
+					}
+				}
+
+				select {
+				case seat.PlayerToRoom <- reply:
+				case <-roomDone:
+					return
+				}
+			}
+		case <-roomDone:
+			return
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/ai"
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func startLocalDriver(t *testing.T) (*LocalDriver, chan messages.ServerMessage) {
+	session := NewSession("", Config{})
+	driver := NewLocalDriver(&session, ai.DifficultyEasy)
+	go driver.Run()
+	t.Cleanup(driver.Close)
+	return driver, session.driverToSession
+}
+
+func expectServerMessage(t *testing.T, ch chan messages.ServerMessage, expected messages.ServerMessageType) messages.ServerMessage {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		if msg.Type != expected {
+			t.Fatalf("expected %v message, got %v (%q)", expected, msg.Type, msg.ErrorMessage)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %v message", expected)
+	}
+	return messages.ServerMessage{}
+}
+
+func joinLocalGame(t *testing.T, driver *LocalDriver, ch chan messages.ServerMessage, gameType game.GameType) messages.ServerMessage {
+	t.Helper()
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientJoinRoom})
+	joined := expectServerMessage(t, ch, messages.ServerRoomJoined)
+	if joined.PlayerNumber != 1 {
+		t.Fatalf("session should take seat one, got seat %d", joined.PlayerNumber)
+	}
+	expectServerMessage(t, ch, messages.ServerEnteredGameSelection)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientSelectGameType, GameType: gameType})
+	return expectServerMessage(t, ch, messages.ServerGameStarted)
+}
+
+func TestLocalDriverPlaysFullGameAgainstBot(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	msg := joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	for msg.Type != messages.ServerGameFinished {
+		if msg.PlayerTurn == 1 {
+			turns := msg.Game.GetGame().GetValidTurns(1)
+			driver.WriteToServer(messages.ClientMessage{
+				Type:       messages.ClientSendTurn,
+				TurnAction: messages.NewGameTurnWrapper(turns[0]),
+			})
+		}
+
+		select {
+		case msg = <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the game to progress")
+		}
+		if msg.Type == messages.ServerError {
+			t.Fatalf("valid turn was rejected: %v", msg.ErrorMessage)
+		}
+	}
+
+	if msg.Game.GetGame().GetGameStatus() == game.GameStatusOngoing {
+		t.Error("finished game should not be ongoing")
+	}
+}
+
+func TestLocalDriverRejectsInvalidTurn(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	turn := messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+	}
+	driver.WriteToServer(turn)
+	expectServerMessage(t, ch, messages.ServerTurnResult)
+	if msg := expectServerMessage(t, ch, messages.ServerTurnResult); msg.PlayerTurn != 1 {
+		t.Fatalf("expected the bot to hand the turn back, got player turn %d", msg.PlayerTurn)
+	}
+
+	driver.WriteToServer(turn)
+	msg := expectServerMessage(t, ch, messages.ServerError)
+	if msg.ErrorMessage != "square is occupied" {
+		t.Errorf("unexpected error message %q", msg.ErrorMessage)
+	}
+}
+
+func TestLocalDriverOpensNewRoomAfterQuit(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeCheckers)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientQuitRoom})
+	expectServerMessage(t, ch, messages.ServerRoomClosed)
+
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+}
+
+func TestHotSeatDriverRoutesTurnsToCurrentPlayer(t *testing.T) {
+	session := NewSession("", Config{})
+	driver := NewHotSeatDriver(&session)
+	go driver.Run()
+	t.Cleanup(driver.Close)
+	ch := session.driverToSession
+
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	//player one takes the top row while player two plays the middle row
+	moves := []vector.Vector{
+		vector.NewVector(0, 0), vector.NewVector(0, 1),
+		vector.NewVector(1, 0), vector.NewVector(1, 1),
+		vector.NewVector(2, 0),
+	}
+	for i, coords := range moves {
+		driver.WriteToServer(messages.ClientMessage{
+			Type:       messages.ClientSendTurn,
+			TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: coords}),
+		})
+		if i == len(moves)-1 {
+			break
+		}
+		msg := expectServerMessage(t, ch, messages.ServerTurnResult)
+		if expectedTurn := (i+1)%2 + 1; msg.PlayerTurn != expectedTurn {
+			t.Fatalf("after move %d expected player %d's turn, got %d", i, expectedTurn, msg.PlayerTurn)
+		}
+	}
+
+	msg := expectServerMessage(t, ch, messages.ServerGameFinished)
+	if status := msg.Game.GetGame().GetGameStatus(); status != game.GameStatusPlayer1Win {
+		t.Errorf("expected player 1 to win, got status %v", status)
+	}
+}
+
+func TestLocalDriverRematchSwapsSides(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientConcede})
+	expectServerMessage(t, ch, messages.ServerGameFinished)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientRematchVote, Rematch: messages.RematchSwapSides})
+	if vote := expectServerMessage(t, ch, messages.ServerRematchVote); vote.PlayerNumber != 1 {
+		t.Fatalf("expected the session's vote first, got player %d", vote.PlayerNumber)
+	}
+	if vote := expectServerMessage(t, ch, messages.ServerRematchVote); vote.Rematch != messages.RematchSwapSides {
+		t.Fatalf("expected the bot to agree to swap sides, got %v", vote.Rematch)
+	}
+
+	msg := expectServerMessage(t, ch, messages.ServerGameStarted)
+	if msg.PlayerNumber != 2 {
+		t.Fatalf("expected the session to be player 2 after swapping, got %d", msg.PlayerNumber)
+	}
+	//the bot is now player one and moves first
+	if msg = expectServerMessage(t, ch, messages.ServerTurnResult); msg.PlayerTurn != 2 {
+		t.Fatalf("expected the bot to hand the turn to player 2, got %d", msg.PlayerTurn)
+	}
+}
+
+func TestLocalDriverRematchNewGame(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientConcede})
+	expectServerMessage(t, ch, messages.ServerGameFinished)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientRematchVote, Rematch: messages.RematchNewGame})
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	expectServerMessage(t, ch, messages.ServerEnteredGameSelection)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientSelectGameType, GameType: game.GameTypeCheckers})
+	msg := expectServerMessage(t, ch, messages.ServerGameStarted)
+	if msg.Game.GetGame().GetGameType() != game.GameTypeCheckers {
+		t.Errorf("expected a checkers game, got %v", msg.Game.GetGame().GetGameType())
+	}
+}
+
+func TestLocalDriverBotPicksGameAfterSwap(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientConcede})
+	expectServerMessage(t, ch, messages.ServerGameFinished)
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientRematchVote, Rematch: messages.RematchSwapSides})
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	if msg := expectServerMessage(t, ch, messages.ServerGameStarted); msg.PlayerNumber != 2 {
+		t.Fatalf("expected the session to move to the second seat, got %d", msg.PlayerNumber)
+	}
+	//the bot has the first move now
+	expectServerMessage(t, ch, messages.ServerTurnResult)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientConcede})
+	expectServerMessage(t, ch, messages.ServerGameFinished)
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientRematchVote, Rematch: messages.RematchNewGame})
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	expectServerMessage(t, ch, messages.ServerRematchVote)
+	expectServerMessage(t, ch, messages.ServerEnteredGameSelection)
+
+	//the bot holds the first seat now, so it is the one to pick
+	msg := expectServerMessage(t, ch, messages.ServerGameStarted)
+	if msg.PlayerNumber != 2 || msg.Game.GetGame().GetGameType() != game.GameTypeCheckers {
+		t.Errorf("expected the bot to start checkers with the session second, got player %d in %v", msg.PlayerNumber, msg.Game.GetGame().GetGameType())
+	}
+}
+
+func TestLocalDriverNamesBothSeats(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientJoinRoom, Nickname: "Ada"})
+	expectServerMessage(t, ch, messages.ServerRoomJoined)
+
+	msg := expectServerMessage(t, ch, messages.ServerEnteredGameSelection)
+	if expected := [2]string{"Ada", "Computer (Easy)"}; msg.PlayerNames != expected {
+		t.Errorf("expected names %q, got %q", expected, msg.PlayerNames)
+	}
+}
+
+func TestLocalDriverStartsFromSetup(t *testing.T) {
+	session := NewSession("", Config{})
+	session = session.StartLocalGameFrom(ai.DifficultyEasy, "B:W18,32:B1")
+	t.Cleanup(session.driver.Close)
+	ch := session.driverToSession
+
+	session.driver.WriteToServer(messages.ClientMessage{Type: messages.ClientJoinRoom})
+	expectServerMessage(t, ch, messages.ServerRoomJoined)
+	msg := expectServerMessage(t, ch, messages.ServerGameStarted)
+	if msg.PlayerTurn != 2 || msg.Game.GetGame().GetGameType() != game.GameTypeCheckers {
+		t.Fatalf("expected checkers with black to move, got player %d in %v", msg.PlayerTurn, msg.Game.GetGame().GetGameType())
+	}
+	//the bot has black and moves first
+	if msg = expectServerMessage(t, ch, messages.ServerTurnResult); msg.PlayerTurn != 1 {
+		t.Errorf("expected the bot to hand the turn to white, got %d", msg.PlayerTurn)
+	}
+}
+
+func TestLocalDriverBotAllowsTakebacksButNotDraws(t *testing.T) {
+	driver, ch := startLocalDriver(t)
+	joinLocalGame(t, driver, ch, game.GameTypeTicTacToe)
+
+	driver.WriteToServer(messages.ClientMessage{
+		Type:       messages.ClientSendTurn,
+		TurnAction: messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+	})
+	expectServerMessage(t, ch, messages.ServerTurnResult)
+	expectServerMessage(t, ch, messages.ServerTurnResult)
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferDraw})
+	if msg := expectServerMessage(t, ch, messages.ServerOfferAnswered); msg.Accepted {
+		t.Error("expected the computer to play on rather than draw")
+	}
+
+	driver.WriteToServer(messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: messages.OfferTakeback})
+	if msg := expectServerMessage(t, ch, messages.ServerOfferAnswered); !msg.Accepted {
+		t.Error("expected the computer to allow the takeback")
+	}
+	msg := expectServerMessage(t, ch, messages.ServerTurnResult)
+	if msg.PlayerTurn != 1 || msg.Game.TicTacToe.Board != game.NewTicTacToeGame().Board {
+		t.Errorf("expected the board to be empty with the session to move, got %+v", msg)
+	}
+}
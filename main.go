@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,7 +32,11 @@ func main() {
 		url = "wss://ascii-arcade-server-714989044760.us-central1.run.app"
 	}
 
-	session := NewSession(url)
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("warning:", err)
+	}
+	session := NewSession(url, config)
 
 	if len(os.Getenv("DEBUG")) > 0 {
 		logger = logging.NewLogger(logging.LoggerDebug)
@@ -40,6 +46,9 @@ func main() {
 			os.Exit(1)
 		}
 		defer f.Close()
+	} else {
+		//local games run the room in this process, keep its logging off the terminal
+		log.SetOutput(io.Discard)
 	}
 	if _, err := tea.NewProgram(session).Run(); err != nil {
 		panic(err)
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const notificationTitle = "ASCII Arcade"
+
+// NotificationStyle - Which escape sequence asks the terminal for a desktop notification. Terminals
+// each understand one or the other, and ignore the ones they do not.
+type NotificationStyle int
+
+const (
+	NotificationOff NotificationStyle = iota
+	//OSC 9 is understood by iTerm2, Windows Terminal, kitty, WezTerm and ConEmu
+	NotificationOSC9
+	//OSC 777 is understood by urxvt, foot, Ghostty and VTE based terminals
+	NotificationOSC777
+)
+
+func GetNotificationStyles() []NotificationStyle {
+	return []NotificationStyle{NotificationOff, NotificationOSC9, NotificationOSC777}
+}
+
+func (style NotificationStyle) String() string {
+	switch style {
+	case NotificationOff:
+		return "Off"
+	case NotificationOSC9:
+		return "OSC 9"
+	case NotificationOSC777:
+		return "OSC 777"
+	default:
+		return "Unknown"
+	}
+}
+
+// notifyOutput - Where the bell and notifications are written. Each is a single write, so it lands
+// whole between the frames the renderer writes to the same terminal.
+var notifyOutput io.Writer = os.Stdout
+
+// notificationSequence - The bytes for a bell and a notification, as the settings ask for them.
+func notificationSequence(bell bool, style NotificationStyle, body string) string {
+	//the body goes inside an escape sequence, so it must not be able to end it early
+	body = strings.ReplaceAll(messages.SanitizeChat(body), ";", ",")
+
+	var sequence strings.Builder
+	if bell {
+		sequence.WriteString("\a")
+	}
+	switch style {
+	case NotificationOSC9:
+		fmt.Fprintf(&sequence, "\x1b]9;%v: %v\a", notificationTitle, body)
+	case NotificationOSC777:
+		fmt.Fprintf(&sequence, "\x1b]777;notify;%v;%v\a", notificationTitle, body)
+	}
+	return sequence.String()
+}
+
+// notify - Rings the bell and raises a notification, if the player has turned either on.
+func (session Session) notify(body string) tea.Cmd {
+	sequence := notificationSequence(session.config.Bell, session.config.Notification, body)
+	if sequence == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, err := io.WriteString(notifyOutput, sequence); err != nil {
+			return ErrMsg{err}
+		}
+		return nil
+	}
+}
+
+// isYourTurn - Whether the player is in a game and it is up to them to move.
+func (session Session) isYourTurn() bool {
+	inGame, ok := session.state.(*SessionStateInGame)
+	return ok && inGame.isPlayerTurn
+}
+
+// notification - What a player who has tabbed away should hear about, after a server message moved
+// the session on from the screen it was on before. Games in this process, against the computer or
+// at a shared keyboard, never keep anyone waiting, so they raise nothing.
+func (session Session) notification(before SessionStateType, wasYourTurn bool) string {
+	if _, local := session.driver.(*LocalDriver); local || session.hotSeat {
+		return ""
+	}
+
+	opponent := playerNames(session.playerNames).name(3 - session.playerNumber)
+	after := session.state.GetType()
+	switch {
+	case before == SessionStateTypeWaitingRoom && (after == SessionStateTypeGameSelection || after == SessionStateTypeInGame):
+		return opponent + " joined your room"
+	case before == SessionStateTypeSearching && after == SessionStateTypeInGame:
+		return "Matched with " + opponent
+	case session.isYourTurn() && !wasYourTurn:
+		return "Your turn against " + opponent
+	}
+	return ""
+}
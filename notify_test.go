@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+)
+
+func TestNotificationSequence(t *testing.T) {
+	for _, test := range []struct {
+		bell     bool
+		style    NotificationStyle
+		expected string
+	}{
+		{false, NotificationOff, ""},
+		{true, NotificationOff, "\a"},
+		{false, NotificationOSC9, "\x1b]9;ASCII Arcade: Your turn, Ada\a"},
+		{true, NotificationOSC777, "\a\x1b]777;notify;ASCII Arcade;Your turn, Ada\a"},
+	} {
+		//the body cannot close the sequence or add fields to it
+		if sequence := notificationSequence(test.bell, test.style, "Your turn; Ada\a\x1b\\"); sequence != test.expected {
+			t.Errorf("bell %v and %v: expected %q, got %q", test.bell, test.style, test.expected, sequence)
+		}
+	}
+}
+
+func TestNotificationOnlyWhenNeeded(t *testing.T) {
+	session := NewSession("", Config{})
+	session.driver = &WSDriver{}
+	session.playerNumber = 1
+	session.playerNames = [2]string{"Ada", "Grace"}
+
+	session.state = NewSessionStateInGameSelection(1)
+	if body := session.notification(SessionStateTypeWaitingRoom, false); body != "Grace joined your room" {
+		t.Errorf("expected to hear the opponent joined, got %q", body)
+	}
+
+	g := game.NewGame(game.GameTypeTicTacToe)
+	session.state = NewSessionStateInGame(1, 1, g, false)
+	if body := session.notification(SessionStateTypeInGame, false); body != "Your turn against Grace" {
+		t.Errorf("expected to hear it is our turn, got %q", body)
+	}
+	if body := session.notification(SessionStateTypeInGame, true); body != "" {
+		t.Errorf("expected nothing while it stays our turn, got %q", body)
+	}
+
+	session.driver = &LocalDriver{}
+	if body := session.notification(SessionStateTypeInGame, false); body != "" {
+		t.Errorf("expected nothing against the computer, got %q", body)
+	}
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/ai"
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/internal/pdn"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+// how many of the player's latest games the replay list asks the server for
+const replayHistorySize = 20
+
+// autoplay speeds as the delay between moves, slowest first
+var replaySpeeds = []time.Duration{2 * time.Second, time.Second, 500 * time.Millisecond, 250 * time.Millisecond}
+
+// ReplayTickMsg - Moves an autoplaying replay on by a turn. Ticks from a run that has since been
+// paused or restarted carry an old generation and are dropped.
+type ReplayTickMsg struct {
+	generation int
+}
+
+// LoadRecordFile - Reads a game exported from the replay screen, or a checkers game in PDN from
+// anywhere else.
+func LoadRecordFile(path string) (messages.GameRecord, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return messages.GameRecord{}, fmt.Errorf("error reading game file: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".pdn") {
+		record, err := pdn.Parse(string(contents))
+		if err != nil {
+			return messages.GameRecord{}, fmt.Errorf("error parsing PDN file: %w", err)
+		}
+		return record, nil
+	}
+
+	var record messages.GameRecord
+	if err := json.Unmarshal(contents, &record); err != nil {
+		return messages.GameRecord{}, fmt.Errorf("error parsing game file: %w", err)
+	}
+	return record, nil
+}
+
+// SaveRecordFile - Writes a game to the working directory to be loaded or shared later, and returns
+// the file's name. Checkers games are written as PDN so other checkers programs can read them.
+func SaveRecordFile(record messages.GameRecord) (string, error) {
+	id := record.ID
+	if id == "" {
+		id = record.Started.Format("20060102-150405")
+	}
+	path := fmt.Sprintf("%v-%v.json", strings.ToLower(record.GameType.String()), id)
+
+	var contents []byte
+	var err error
+	if record.GameType == game.GameTypeCheckers {
+		path = strings.TrimSuffix(path, ".json") + ".pdn"
+		var text string
+		text, err = pdn.Write(record)
+		contents = []byte(text)
+	} else {
+		contents, err = json.MarshalIndent(record, "", "  ")
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, contents, 0o644); err != nil {
+		return "", fmt.Errorf("error writing game file: %w", err)
+	}
+	return path, nil
+}
+
+// recordOutcome - How the game ended, told from the point of view of the given account if they
+// played in it.
+func recordOutcome(record messages.GameRecord, username string) string {
+	if !record.HasResult() {
+		return "Unfinished"
+	}
+	for i, account := range record.Accounts {
+		if account == "" || !strings.EqualFold(account, username) {
+			continue
+		}
+		switch record.Results[i] {
+		case messages.GameResultPlayerWin:
+			return "Won"
+		case messages.GameResultPlayerLose:
+			return "Lost"
+		case messages.GameResultDraw:
+			return "Draw"
+		}
+	}
+
+	switch {
+	case record.Results[0] == messages.GameResultDraw:
+		return "Draw"
+	case record.QuittingPlayerNum != 0:
+		return recordPlayer(record, record.QuittingPlayerNum) + " left"
+	case record.TimedOutPlayerNum != 0:
+		return recordPlayer(record, record.TimedOutPlayerNum) + " lost on time"
+	case record.Results[0] == messages.GameResultPlayerWin:
+		return recordPlayer(record, 1) + " won"
+	default:
+		return recordPlayer(record, 2) + " won"
+	}
+}
+
+func recordPlayer(record messages.GameRecord, playerNumber int) string {
+	if name := record.Players[playerNumber-1]; name != "" {
+		return name
+	}
+	return fmt.Sprintf("Player %d", playerNumber)
+}
+
+// SessionStateReplayList - The logged in player's latest games, plus a way to open a game file.
+type SessionStateReplayList struct {
+	records []messages.GameRecord
+	cursor  int
+	loaded  bool
+	//username picks which side of each game to tell the result from, empty when not logged in
+	username string
+	//while enteringPath, keys go to the path box instead of the list
+	pathInput    textinput.Model
+	enteringPath bool
+}
+
+func NewSessionStateReplayList(username string) *SessionStateReplayList {
+	pathInput := textinput.New()
+	pathInput.Placeholder = "path/to/game.json or .pdn"
+	pathInput.Width = 40
+	return &SessionStateReplayList{username: username, pathInput: pathInput}
+}
+
+func (state SessionStateReplayList) GetType() SessionStateType {
+	return SessionStateTypeReplayList
+}
+
+func (state SessionStateReplayList) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1)
+
+	unselectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(0, 1)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("🎞 REPLAYS")
+	controls := controlsStyle.Render("↑/↓ Navigate • Enter Watch • o Open File • q Back to Menu")
+
+	var list string
+	switch {
+	case state.username == "":
+		list = statusStyle.Render("Log in from the Account screen to see your games, or open a game file.")
+	case !state.loaded:
+		list = statusStyle.Render("Loading your games...")
+	case len(state.records) == 0:
+		list = statusStyle.Render("No games yet. Finish a game while logged in and it shows up here.")
+	default:
+		rowFormat := "%-12s %-10s %-32s %s"
+		rows := []string{headerStyle.Render(fmt.Sprintf("  "+rowFormat, "PLAYED", "GAME", "PLAYERS", "RESULT"))}
+		for i, record := range state.records {
+			players := recordPlayer(record, 1) + " vs " + recordPlayer(record, 2)
+			row := fmt.Sprintf(rowFormat, record.Started.Local().Format("Jan 02 15:04"), record.GameType.String(), players, recordOutcome(record, state.username))
+			if i == state.cursor {
+				rows = append(rows, selectedStyle.Render("▶ "+row))
+			} else {
+				rows = append(rows, unselectedStyle.Render("  "+row))
+			}
+		}
+		list = lipgloss.JoinVertical(lipgloss.Left, rows...)
+	}
+
+	if state.enteringPath {
+		prompt := boxStyle.Render("📂 Game file\n" + state.pathInput.View() + "\n\nEnter Open • Esc Cancel")
+		return lipgloss.JoinVertical(lipgloss.Left, title, list, prompt, controls)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, title, list, controls)
+}
+
+func (state *SessionStateReplayList) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if state.enteringPath {
+		return state.handlePathInput(msg, session)
+	}
+
+	switch msg.String() {
+	case "up", "w", "k":
+		if state.cursor > 0 {
+			state.cursor--
+		}
+	case "down", "s", "j":
+		if state.cursor < len(state.records)-1 {
+			state.cursor++
+		}
+	case "enter", " ":
+		if state.cursor >= len(state.records) {
+			return session, nil
+		}
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:   messages.ClientFetchGame,
+			GameID: state.records[state.cursor].ID,
+		})
+	case "o":
+		state.enteringPath = true
+		state.pathInput.Reset()
+		return session, state.pathInput.Focus()
+	case "q", "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	}
+	return session, nil
+}
+
+func (state *SessionStateReplayList) handlePathInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		state.enteringPath = false
+		state.pathInput.Blur()
+		return session, nil
+	case "enter":
+		record, err := LoadRecordFile(strings.TrimSpace(state.pathInput.Value()))
+		if err == nil {
+			session, err = state.watch(session, record)
+		}
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		return session, nil
+	}
+
+	var cmd tea.Cmd
+	state.pathInput, cmd = state.pathInput.Update(msg)
+	return session, cmd
+}
+
+// watch - Opens the replay screen on a record, as long as it can be played back at all.
+func (state *SessionStateReplayList) watch(session Session, record messages.GameRecord) (Session, error) {
+	if positions, err := record.Positions(); len(positions) == 0 {
+		return session, err
+	}
+	session.replay = record
+	return session.setState(SessionStateTypeReplay), nil
+}
+
+func (state *SessionStateReplayList) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerGameHistory:
+		state.records = msg.Records
+		state.loaded = true
+		state.cursor = 0
+	case messages.ServerGameRecord:
+		return state.watch(session, msg.Record)
+	case messages.ServerError:
+		state.loaded = true
+		return session, errors.New(msg.ErrorMessage)
+	default:
+		return session, fmt.Errorf("unexpected server message type on the replay list: %v", msg.Type)
+	}
+	return session, nil
+}
+
+// SessionStateReplay - Steps through a finished game one turn at a time, or plays it back on its own.
+type SessionStateReplay struct {
+	record    messages.GameRecord
+	positions []game.Game
+	//replayErr is why the positions stop short of the end of the record, if they do
+	replayErr error
+	//ply is how many turns into the game the board is shown
+	ply int
+	//viewingPlayer is whose side of the board is shown at the bottom
+	viewingPlayer int
+
+	playing    bool
+	speed      int
+	generation int
+
+	//while jumping, keys go to the ply box
+	plyInput textinput.Model
+	jumping  bool
+	//notice confirms an export
+	notice string
+}
+
+func NewSessionStateReplay(record messages.GameRecord, username string) *SessionStateReplay {
+	positions, err := record.Positions()
+
+	viewingPlayer := 1
+	if username != "" && strings.EqualFold(record.Accounts[1], username) {
+		viewingPlayer = 2
+	}
+
+	plyInput := textinput.New()
+	plyInput.Placeholder = "Move number"
+	plyInput.CharLimit = 5
+	plyInput.Width = 12
+
+	return &SessionStateReplay{
+		record:        record,
+		positions:     positions,
+		replayErr:     err,
+		viewingPlayer: viewingPlayer,
+		speed:         1,
+		plyInput:      plyInput,
+	}
+}
+
+func (state SessionStateReplay) GetType() SessionStateType {
+	return SessionStateTypeReplay
+}
+
+func (state SessionStateReplay) lastPly() int {
+	return len(state.positions) - 1
+}
+
+func (state SessionStateReplay) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	infoStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Background(lipgloss.Color("#1C1C1E")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	resultStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#FF9500")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(0, 1)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1)
+
+	title := titleStyle.Render(fmt.Sprintf("🎞 REPLAY | %v | %v vs %v",
+		state.record.GameType, recordPlayer(state.record, 1), recordPlayer(state.record, 2)))
+	board := state.positions[state.ply].DisplayBoard(vector.NewVector(-1, -1), state.viewingPlayer)
+
+	moveInfo := "Start of the game"
+	if state.ply > 0 {
+		turn := state.record.Turns[state.ply-1]
+		moveInfo = fmt.Sprintf("Move %d of %d • %v moved", state.ply, len(state.record.Turns), recordPlayer(state.record, turn.PlayerNumber))
+		if !turn.Time.IsZero() && !state.record.Started.IsZero() {
+			moveInfo += " at " + turn.Time.Sub(state.record.Started).Truncate(time.Second).String()
+		}
+	}
+	playback := "⏸ Paused"
+	if state.playing {
+		playback = fmt.Sprintf("▶ Playing, %v per move", replaySpeeds[state.speed])
+	}
+	if state.notice != "" {
+		playback += " | " + state.notice
+	}
+	info := infoStyle.Render(moveInfo + " | " + playback)
+
+	parts := []string{title, board, info}
+	if state.ply == state.lastPly() {
+		if state.replayErr != nil {
+			parts = append(parts, resultStyle.Render("Replay stops here: "+state.replayErr.Error()))
+		} else {
+			parts = append(parts, resultStyle.Render(recordOutcome(state.record, "")))
+		}
+	}
+	if state.jumping {
+		parts = append(parts, boxStyle.Render("Jump to move (0 - "+strconv.Itoa(state.lastPly())+")\n"+state.plyInput.View()))
+	}
+
+	help := "←/→ Step • Home/End Start/End • g Jump to Move • Space Play/Pause • +/- Speed • f Flip Board • e Export • q Back to Menu"
+	if state.record.GameType == game.GameTypeCheckers {
+		help = "←/→ Step • Home/End Start/End • g Jump to Move • Space Play/Pause • +/- Speed • f Flip Board • e Export\np Play On From Here • q Back to Menu"
+	}
+	controls := controlsStyle.Render(help)
+	parts = append(parts, controls)
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+func (state *SessionStateReplay) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if state.jumping {
+		return state.handleJumpInput(msg, session)
+	}
+
+	switch msg.String() {
+	case "left", "h", "a":
+		state.playing = false
+		state.ply = max(state.ply-1, 0)
+	case "right", "l", "d":
+		state.playing = false
+		state.ply = min(state.ply+1, state.lastPly())
+	case "home":
+		state.playing = false
+		state.ply = 0
+	case "end":
+		state.playing = false
+		state.ply = state.lastPly()
+	case "g":
+		state.playing = false
+		state.jumping = true
+		state.plyInput.Reset()
+		return session, state.plyInput.Focus()
+	case " ", "enter":
+		if state.playing {
+			state.playing = false
+			return session, nil
+		}
+		//playing from the end starts again from the beginning
+		if state.ply == state.lastPly() {
+			state.ply = 0
+		}
+		state.playing = true
+		return session, state.nextTick()
+	case "+", "=":
+		state.speed = min(state.speed+1, len(replaySpeeds)-1)
+	case "-", "_":
+		state.speed = max(state.speed-1, 0)
+	case "f":
+		state.viewingPlayer = 3 - state.viewingPlayer
+	case "e":
+		path, err := SaveRecordFile(state.record)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		state.notice = "Saved the game to " + path
+	case "p":
+		return state.playFromHere(session)
+	case "q", "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	}
+	return session, nil
+}
+
+func (state *SessionStateReplay) handleJumpInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		state.jumping = false
+		state.plyInput.Blur()
+		return session, nil
+	case "enter":
+		ply, err := strconv.Atoi(strings.TrimSpace(state.plyInput.Value()))
+		if err != nil || ply < 0 || ply > state.lastPly() {
+			return session, func() tea.Msg {
+				return ErrMsg{fmt.Errorf("Pick a move from 0 to %d.", state.lastPly())}
+			}
+		}
+		state.ply = ply
+		state.jumping = false
+		state.plyInput.Blur()
+		return session, nil
+	}
+
+	var cmd tea.Cmd
+	state.plyInput, cmd = state.plyInput.Update(msg)
+	return session, cmd
+}
+
+// nextTick - Schedules the next autoplay step. Starting a new run retires any tick still pending.
+func (state *SessionStateReplay) nextTick() tea.Cmd {
+	state.generation++
+	generation := state.generation
+	return tea.Tick(replaySpeeds[state.speed], func(time.Time) tea.Msg {
+		return ReplayTickMsg{generation: generation}
+	})
+}
+
+// tick - Steps forward while autoplaying, and stops at the end of the game.
+func (state *SessionStateReplay) tick(msg ReplayTickMsg) tea.Cmd {
+	if !state.playing || msg.generation != state.generation {
+		return nil
+	}
+	state.ply = min(state.ply+1, state.lastPly())
+	if state.ply == state.lastPly() {
+		state.playing = false
+		return nil
+	}
+	return state.nextTick()
+}
+
+// sideToMove - Whose turn it is in the position on the board.
+func (state SessionStateReplay) sideToMove() int {
+	turns := state.record.Turns
+	switch {
+	case state.ply < len(turns):
+		return turns[state.ply].PlayerNumber
+	case state.ply > 0:
+		return 3 - turns[state.ply-1].PlayerNumber
+	case state.record.Setup != "":
+		if _, playerToMove, err := game.ParseCheckersFEN(state.record.Setup); err == nil {
+			return playerToMove
+		}
+	}
+	return 1
+}
+
+// playFromHere - Starts a checkers game against the computer from the position on the board, with
+// this player taking white.
+func (state *SessionStateReplay) playFromHere(session Session) (tea.Model, tea.Cmd) {
+	position, ok := state.positions[state.ply].(*game.CheckersGame)
+	if !ok {
+		return session, func() tea.Msg { return ErrMsg{errors.New("Only checkers can be played on from a replay.")} }
+	}
+	if position.GetGameStatus() != game.GameStatusOngoing {
+		return session, func() tea.Msg { return ErrMsg{errors.New("The game is already over here.")} }
+	}
+	//the game is already starting
+	if session.driver != nil {
+		return session, nil
+	}
+
+	state.playing = false
+	session = session.StartLocalGameFrom(ai.DifficultyMedium, position.FEN(state.sideToMove()))
+	session.roomCode = localRoomCode
+	return session, session.SendMsgToServer(messages.ClientMessage{Type: messages.ClientJoinRoom})
+}
+
+// handleServerMessage - A replay has no connection of its own, so the only messages are from a game
+// started from the board.
+func (state *SessionStateReplay) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerRoomJoined:
+		session.playerNumber = msg.PlayerNumber
+	case messages.ServerGameStarted:
+		session.playerNumber = msg.PlayerNumber
+		session.game = msg.Game.GetGame()
+		session.gameType = session.game.GetGameType()
+		session.playerTurn = msg.PlayerTurn
+		session = session.setState(SessionStateTypeInGame)
+	default:
+		return session, fmt.Errorf("unexpected server message type during a replay: %v", msg.Type)
+	}
+	return session, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func TestRecordFileRoundTrip(t *testing.T) {
+	t.Chdir(t.TempDir())
+	record := messages.GameRecord{
+		ID:       "0123456789abcdef",
+		GameType: game.GameTypeTicTacToe,
+		Players:  [2]string{"Ada", "Grace"},
+		Started:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Turns: []messages.RecordedTurn{{
+			PlayerNumber: 1,
+			Turn:         messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+		}},
+		Results: [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose},
+	}
+
+	path, err := SaveRecordFile(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "tictactoe-0123456789abcdef.json" {
+		t.Errorf("unexpected file name %q", path)
+	}
+
+	loaded, err := LoadRecordFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Players != record.Players || len(loaded.Turns) != 1 || loaded.Turns[0].Turn != record.Turns[0].Turn {
+		t.Errorf("expected the game back as saved, got %+v", loaded)
+	}
+}
+
+func TestRecordOutcome(t *testing.T) {
+	record := messages.GameRecord{
+		Players:  [2]string{"Ada", ""},
+		Accounts: [2]string{"Ada", ""},
+		Results:  [2]messages.GameResult{messages.GameResultPlayerLose, messages.GameResultPlayerWin},
+	}
+	if outcome := recordOutcome(record, "ada"); outcome != "Lost" {
+		t.Errorf("expected Ada to have lost, got %q", outcome)
+	}
+	if outcome := recordOutcome(record, ""); outcome != "Player 2 won" {
+		t.Errorf("expected the guest to have won, got %q", outcome)
+	}
+
+	record.QuittingPlayerNum = 1
+	if outcome := recordOutcome(record, ""); outcome != "Ada left" {
+		t.Errorf("expected Ada to have left, got %q", outcome)
+	}
+}
+
+func TestCheckersRecordFileIsPDN(t *testing.T) {
+	t.Chdir(t.TempDir())
+	record := messages.GameRecord{
+		ID:       "89abcdef01234567",
+		GameType: game.GameTypeCheckers,
+		Players:  [2]string{"Ada", "Grace"},
+		Started:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Turns: []messages.RecordedTurn{{
+			PlayerNumber: 1,
+			Turn:         messages.NewGameTurnWrapper(game.CheckersTurn{PieceCoords: vector.NewVector(1, 5), Direction: game.CheckersDirectionRight}),
+		}},
+	}
+
+	path, err := SaveRecordFile(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "checkers-89abcdef01234567.pdn" {
+		t.Errorf("unexpected file name %q", path)
+	}
+
+	loaded, err := LoadRecordFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Players != record.Players || len(loaded.Turns) != 1 || loaded.Turns[0].Turn != record.Turns[0].Turn {
+		t.Errorf("expected the game back as saved, got %+v", loaded)
+	}
+	if outcome := recordOutcome(loaded, ""); outcome != "Unfinished" {
+		t.Errorf("expected a game without a result to be unfinished, got %q", outcome)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Heartbeat - How often the server pings each connection, and how long the pong may take to come
+// back. A connection that misses its pong is treated as dropped, so a seated player's reconnect
+// grace period starts instead of the seat lingering on a half-open connection. A zero Interval
+// turns the heartbeat off.
+type Heartbeat struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// readDeadline - How long a connection can go quiet before it is given up on. Every pong moves it on.
+func (heartbeat Heartbeat) readDeadline() time.Time {
+	return time.Now().Add(heartbeat.Interval + heartbeat.Timeout)
+}
+
+// startHeartbeat - Pings the player's connection until stop is closed. Pings go out with
+// WriteControl, which gorilla allows alongside the writes Run makes.
+func (player *Player) startHeartbeat(stop <-chan struct{}) {
+	heartbeat := player.hub.heartbeat
+	if heartbeat.Interval <= 0 {
+		return
+	}
+
+	player.conn.SetReadDeadline(heartbeat.readDeadline())
+	player.conn.SetPongHandler(func(string) error {
+		return player.conn.SetReadDeadline(heartbeat.readDeadline())
+	})
+
+	go func() {
+		ticker := time.NewTicker(heartbeat.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := player.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(heartbeat.Timeout))
+				if err != nil {
+					//the read deadline will catch the connection, there is nothing more to do here
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
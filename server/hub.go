@@ -1,11 +1,21 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/server/store"
 )
 
 var upgrader = websocket.Upgrader{
@@ -16,44 +26,369 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-type RoomRequest struct {
-	code  string
-	chans RoomChans
+type Hub struct {
+	roomRequests  chan gameroom.Request
+	lobbyRequests chan chan []messages.RoomInfo
+	matchRequests chan matchRequest
+	roomOptions   gameroom.Options
+	heartbeat     Heartbeat
+	accounts      store.Store
+	//goingAway is closed once the server starts shutting down, for every player to pass on, and
+	//stopped once every room has drained, for the players to hang up
+	goingAway chan struct{}
+	stopped   chan struct{}
+	//players counts the open connections
+	players sync.WaitGroup
 }
 
-type Hub struct {
-	roomRequests chan RoomRequest
+const (
+	//how far apart two players' ratings may be for a quick match, widening the longer they wait
+	matchBaseReach     = 100
+	matchReachGrowth   = 50
+	matchReachInterval = 5 * time.Second
+	//how often waiting players are looked at again, in case their reach now covers someone
+	matchRetryInterval = time.Second
+)
+
+// matchRequest - A player asking to be paired with a stranger, or calling off their search.
+type matchRequest struct {
+	gameType game.GameType
+	nickname string
+	account  string
+	chans    gameroom.Chans
+	cancel   bool
+	//filled in by the hub as the player joins the queue
+	rating   int
+	queuedAt time.Time
 }
 
-func NewHub() *Hub {
-	return &Hub{make(chan RoomRequest)}
+func NewHub(roomOptions gameroom.Options, heartbeat Heartbeat, accounts store.Store) *Hub {
+	h := &Hub{
+		make(chan gameroom.Request),
+		make(chan chan []messages.RoomInfo),
+		make(chan matchRequest),
+		roomOptions,
+		heartbeat,
+		accounts,
+		make(chan struct{}),
+		make(chan struct{}),
+		sync.WaitGroup{},
+	}
+	h.roomOptions.OnResult = h.recordResult
+	return h
+}
+
+// recordResult - Keeps the game's record, and adds it to the stats of whichever players were logged
+// in, and to their ratings if the room was rated.
+func (h *Hub) recordResult(result gameroom.Result) {
+	if err := h.accounts.RecordGame(result.GameType, result.Accounts, result.Results, result.Rated); err != nil {
+		log.Printf("Error recording result for %v: %v", result.Accounts, err)
+	}
+	id, err := h.accounts.SaveRecord(result.Record)
+	if err != nil {
+		log.Printf("Error saving game record: %v", err)
+		return
+	}
+	log.Printf("Saved %v game %v", result.GameType, id)
 }
 
-func (h *Hub) Run() {
-	rooms := make(map[string]*Room)
+// rating - A player's rating at the game type. Guests are rated like a brand new account.
+func (h *Hub) rating(account string, gameType game.GameType) int {
+	if account == "" {
+		return store.DefaultRating
+	}
+	found, err := h.accounts.Account(account)
+	if err != nil {
+		return store.DefaultRating
+	}
+	return found.Rating(gameType)
+}
+
+// ListRooms - Lists the public rooms. Only the hub goroutine may touch the rooms map, so the
+// listing is put together there and handed back.
+func (h *Hub) ListRooms() []messages.RoomInfo {
+	reply := make(chan []messages.RoomInfo, 1)
+	h.lobbyRequests <- reply
+	return <-reply
+}
+
+// Run - Runs the rooms and the quick match queues. Once ctx is cancelled no new rooms are made, and
+// Run returns when the rooms it has have all drained.
+func (h *Hub) Run(ctx context.Context) {
+	rooms := make(map[string]*gameroom.Room)
 	closeReq := make(chan string)
+	//players waiting for a quick match, oldest first
+	queues := make(map[game.GameType][]matchRequest)
+	matchTicker := time.NewTicker(matchRetryInterval)
+	defer matchTicker.Stop()
 
+	shutdown := ctx.Done()
+	draining := false
 	for {
 		select {
+		case <-shutdown:
+			shutdown = nil
+			draining = true
+			log.Printf("Shutting down, waiting on %v rooms", len(rooms))
+			close(h.goingAway)
+			abandonSearches(queues)
 		case msg := <-h.roomRequests:
-			room, ok := rooms[msg.code]
+			//existing rooms can still be joined, resumed and left while they drain
+			if draining && msg.Create {
+				rejectRequest(msg, gameroom.ErrShuttingDown)
+				break
+			}
+			if msg.ResumeToken == "" && !msg.Leave {
+				nickname, err := gameroom.NormalizeNickname(msg.Nickname)
+				if err != nil {
+					rejectRequest(msg, err)
+					break
+				}
+				msg.Nickname = nickname
+			}
+			if msg.Create {
+				msg.Code = gameroom.NewCode(func(code string) bool {
+					_, taken := rooms[code]
+					return taken
+				})
+				log.Printf("Creating new room with code: %v", msg.Code)
+				options := h.roomOptions
+				options.Public = msg.Public
+				options.Password = msg.Password
+				options.Rated = msg.Rated
+				//the server's time control is for quick matches, a created room has the one its creator picked
+				options.TimeControl = msg.TimeControl
+				room := gameroom.NewRoom(msg.Code, closeReq, options)
+				go room.Run(ctx)
+				rooms[msg.Code] = room
+			}
+			if err := gameroom.ValidateCode(msg.Code); err != nil {
+				rejectRequest(msg, err)
+				break
+			}
+			room, ok := rooms[msg.Code]
 			if !ok {
-				log.Printf("Creating new room with code: %v", msg.code)
-				room = NewRoom(msg.code, closeReq)
-				go room.Run()
-				rooms[msg.code] = room
+				//for a resume, the room closed while the player was away and there is nothing to come back to
+				rejectRequest(msg, gameroom.ErrRoomNotFound)
+				break
+			}
+			//a resume token already proves the player belongs, and leaving needs no proof
+			if msg.ResumeToken == "" && !msg.Leave && !room.CheckPassword(msg.Password) {
+				rejectRequest(msg, gameroom.ErrWrongPassword)
+				break
+			}
+			if !room.Join(msg) {
+				//the room has stopped, and its close request is queued behind this one
+				rejectRequest(msg, gameroom.ErrRoomClosing)
+			}
+		case reply := <-h.lobbyRequests:
+			reply <- listPublicRooms(rooms)
+		case req := <-h.matchRequests:
+			if req.cancel {
+				cancelSearch(queues, req)
+				break
+			}
+			if draining {
+				req.chans.RoomToPlayer <- messages.ServerMessage{
+					Type:         messages.ServerError,
+					ErrorMessage: gameroom.ErrShuttingDown.Error(),
+				}
+				break
+			}
+			if !slices.Contains(game.GetGameTypes(), req.gameType) {
+				req.chans.RoomToPlayer <- messages.ServerMessage{
+					Type:         messages.ServerError,
+					ErrorMessage: "unknown game type",
+				}
+				break
+			}
+			nickname, err := gameroom.NormalizeNickname(req.nickname)
+			if err != nil {
+				req.chans.RoomToPlayer <- messages.ServerMessage{
+					Type:         messages.ServerError,
+					ErrorMessage: err.Error(),
+				}
+				break
+			}
+			req.nickname = nickname
+			req.rating = h.rating(req.account, req.gameType)
+			req.queuedAt = time.Now()
+
+			queues[req.gameType] = append(queues[req.gameType], req)
+			h.matchQueue(ctx, rooms, closeReq, queues, req.gameType, req.queuedAt)
+		case now := <-matchTicker.C:
+			for gameType, queue := range queues {
+				if len(queue) >= 2 {
+					h.matchQueue(ctx, rooms, closeReq, queues, gameType, now)
+				}
 			}
-			room.requests <- msg
 		case code := <-closeReq:
 			room, ok := rooms[code]
 			if !ok {
 				log.Println("Error closing room - room closed closeReq channel.")
 				break
 			}
-			log.Printf("Closing room %v\n", room.code)
-			close(room.requests)
+			log.Printf("Closing room %v\n", room.Code())
+			room.Close()
 			delete(rooms, code)
 		}
+		if draining && len(rooms) == 0 {
+			log.Println("All rooms closed")
+			close(h.stopped)
+			return
+		}
+	}
+}
+
+// matchQueue - Starts a room for every pair of players in the game type's queue who are close
+// enough in rating, and leaves everyone else waiting.
+func (h *Hub) matchQueue(ctx context.Context, rooms map[string]*gameroom.Room, closeReq chan string, queues map[game.GameType][]matchRequest, gameType game.GameType, now time.Time) {
+	pairs, waiting := pairPlayers(queues[gameType], now)
+	var unseated []matchRequest
+	for _, pair := range pairs {
+		unseated = append(unseated, h.startMatch(ctx, rooms, closeReq, gameType, pair[0], pair[1])...)
+	}
+	//a player who dropped before being seated is gone, but their opponent goes back to the front
+	queues[gameType] = append(unseated, waiting...)
+}
+
+// pairPlayers - Goes through the queue oldest first, pairing each player with the closest rated
+// player either of them can reach. Returns the pairs and, still in order, everyone left waiting.
+func pairPlayers(queue []matchRequest, now time.Time) ([][2]matchRequest, []matchRequest) {
+	paired := make([]bool, len(queue))
+	var pairs [][2]matchRequest
+	for i, player := range queue {
+		if paired[i] {
+			continue
+		}
+		best, bestGap := -1, 0
+		for j := i + 1; j < len(queue); j++ {
+			gap := abs(player.rating - queue[j].rating)
+			reach := max(ratingReach(player, now), ratingReach(queue[j], now))
+			if paired[j] || gap > reach || (best != -1 && gap >= bestGap) {
+				continue
+			}
+			best, bestGap = j, gap
+		}
+		if best == -1 {
+			continue
+		}
+		paired[i], paired[best] = true, true
+		pairs = append(pairs, [2]matchRequest{player, queue[best]})
+	}
+
+	var waiting []matchRequest
+	for i, req := range queue {
+		if !paired[i] {
+			waiting = append(waiting, req)
+		}
+	}
+	return pairs, waiting
+}
+
+// ratingReach - How far from their own rating a player will accept an opponent. Nobody waits
+// forever for a perfect match.
+func ratingReach(req matchRequest, now time.Time) int {
+	waited := now.Sub(req.queuedAt)
+	return matchBaseReach + matchReachGrowth*int(waited/matchReachInterval)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// startMatch - Opens a private room with a fresh code for two queued players. The room starts
+// the game as soon as the second one is seated. Returns whoever could not be seated because the
+// room stopped first.
+func (h *Hub) startMatch(ctx context.Context, rooms map[string]*gameroom.Room, closeReq chan string, gameType game.GameType, first, second matchRequest) []matchRequest {
+	code := gameroom.NewCode(func(code string) bool {
+		_, taken := rooms[code]
+		return taken
+	})
+	log.Printf("Matched two players for %v in room %v", gameType, code)
+
+	options := h.roomOptions
+	options.AutoStart = true
+	options.GameType = gameType
+	options.Rated = true
+	room := gameroom.NewRoom(code, closeReq, options)
+	go room.Run(ctx)
+	rooms[code] = room
+
+	var unseated []matchRequest
+	for _, req := range []matchRequest{first, second} {
+		if !room.Join(gameroom.Request{Code: code, Chans: req.chans, Nickname: req.nickname, Account: req.account}) {
+			unseated = append(unseated, req)
+		}
+	}
+	return unseated
+}
+
+// cancelSearch - Takes a player out of the queue. A player who has already been matched is left
+// alone, they hear about their room instead.
+func cancelSearch(queues map[game.GameType][]matchRequest, req matchRequest) {
+	for gameType, queue := range queues {
+		i := slices.IndexFunc(queue, func(queued matchRequest) bool {
+			return queued.chans == req.chans
+		})
+		if i == -1 {
+			continue
+		}
+		queues[gameType] = slices.Delete(queue, i, i+1)
+		req.chans.RoomToPlayer <- messages.ServerMessage{Type: messages.ServerSearchCancelled}
+		return
+	}
+}
+
+// abandonSearches - Empties the quick match queues, telling everyone waiting that no match is coming.
+func abandonSearches(queues map[game.GameType][]matchRequest) {
+	for gameType, queue := range queues {
+		for _, req := range queue {
+			req.chans.RoomToPlayer <- messages.ServerMessage{
+				Type:         messages.ServerError,
+				ErrorMessage: gameroom.ErrShuttingDown.Error(),
+			}
+		}
+		delete(queues, gameType)
+	}
+}
+
+func listPublicRooms(rooms map[string]*gameroom.Room) []messages.RoomInfo {
+	var infos []messages.RoomInfo
+	for _, room := range rooms {
+		if room.IsPublic() {
+			infos = append(infos, room.Info())
+		}
+	}
+	slices.SortFunc(infos, func(a, b messages.RoomInfo) int {
+		return cmp.Compare(a.Code, b.Code)
+	})
+	return infos
+}
+
+// rejectRequest - Tells a player their request could not reach a room, and why.
+func rejectRequest(req gameroom.Request, err error) {
+	switch {
+	case req.Leave:
+		//the spectator has already left, so there is no one to tell
+	case req.ResumeToken != "":
+		req.Chans.RoomToPlayer <- messages.ServerMessage{
+			Type:         messages.ServerResumeFailed,
+			ErrorMessage: "the room has closed",
+		}
+	case errors.Is(err, gameroom.ErrWrongPassword):
+		req.Chans.RoomToPlayer <- messages.ServerMessage{
+			Type:         messages.ServerPasswordRequired,
+			ErrorMessage: err.Error(),
+		}
+	default:
+		req.Chans.RoomToPlayer <- messages.ServerMessage{
+			Type:         messages.ServerRoomUnavailable,
+			ErrorMessage: err.Error(),
+		}
 	}
 }
 
@@ -65,7 +400,8 @@ func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Println("New connection established, creating player.")
-	player := NewPlayer(conn, h.roomRequests)
+	player := NewPlayer(conn, h)
+	h.players.Add(1)
 
 	go player.Run()
 }
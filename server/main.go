@@ -1,17 +1,71 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/server/store"
 	// _ "net/http/pprof"
 )
 
 func main() {
+	reconnectGrace := flag.Duration("reconnect-grace", time.Minute, "how long a disconnected player's seat is held for them")
+	dataDir := flag.String("data-dir", "data", "directory where player accounts are kept")
+	pingInterval := flag.Duration("ping-interval", 20*time.Second, "how often each connection is pinged, 0 to turn the heartbeat off")
+	pongTimeout := flag.Duration("pong-timeout", 10*time.Second, "how long a pong may take before the connection counts as dropped")
+	drainWindow := flag.Duration("drain-window", 7*time.Second, "how long games in progress may carry on once the server is told to stop")
+	matchClock := flag.String("match-clock", "5m+5s", `time control for quick matches, like "5m+5s", "30s/move" or "none"`)
+	flag.Parse()
+
+	timeControl, err := messages.ParseTimeControl(*matchClock)
+	if err != nil {
+		log.Fatalf("Error reading -match-clock: %v", err)
+	}
+
 	log.Println("Starting server...")
-	hub := NewHub()
-	go hub.Run()
+	accounts, err := store.OpenFileStore(*dataDir)
+	if err != nil {
+		log.Fatalf("Error opening account store: %v", err)
+	}
+	heartbeat := Heartbeat{Interval: *pingInterval, Timeout: *pongTimeout}
+	options := gameroom.Options{ReconnectGrace: *reconnectGrace, TimeControl: timeControl, DrainWindow: *drainWindow}
+	hub := NewHub(options, heartbeat, accounts)
+
+	//SIGTERM is how hosts like Cloud Run ask the server to stop, ahead of killing it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hubDone := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(hubDone)
+	}()
 
 	http.HandleFunc("/", hub.ServeWs)
+	server := &http.Server{Addr: ":8000"}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
 
-	log.Fatal(http.ListenAndServe(":8000", nil))
+	<-ctx.Done()
+	log.Printf("Stopping, games in progress have %v to finish", *drainWindow)
+	//no new connections from here on, the websockets already open are left for the hub to drain
+	if err := server.Shutdown(context.Background()); err != nil {
+		log.Printf("Error closing the listener: %v", err)
+	}
+	<-hubDone
+	if !hub.waitForPlayers(signOffTimeout) {
+		log.Println("Some connections did not close in time")
+	}
+	log.Println("Server stopped")
 }
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
 	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/server/store"
 )
 
 type PlayerMessageType int
@@ -14,16 +18,30 @@ const (
 	PlayerSendMove PlayerMessageType = iota
 )
 
-type RoomChans struct {
-	roomToPlayer chan messages.ServerMessage
-	playerToRoom chan messages.ClientMessage
-}
+//players now stay in a room after a game, so a room closing must not lose its last message
+const roomMessageBuffer = 8
+
+//a resumed connection may already own the seat, in which case no room is reading from this player
+const disconnectSendTimeout = 5 * time.Second
+
+//wrong room or account passwords allowed before the connection is dropped, so neither can be brute
+//forced. Each kind is counted on its own
+const maxPasswordAttempts = 5
+
+//how many players a leaderboard lists
+const leaderboardSize = 20
+
+//the most games a player can list at once from their history
+const maxHistorySize = 50
 
 type Player struct {
 	notInRoom       PlayerStateNotInRoom
 	waitingRoom     PlayerStateWaitingRoom
 	inGameSelection PlayerStateInGameSelection
 	inRoom          PlayerStateInRoom
+	postGame        PlayerStatePostGame
+	spectating      PlayerStateSpectating
+	searching       PlayerStateSearching
 	// waitForClose   PlayerStateWaitForClose
 	state PlayerState
 
@@ -32,22 +50,32 @@ type Player struct {
 
 	playerNumber int
 
-	roomRequests chan RoomRequest
-	clientRead   chan messages.ClientMessage
-	room         RoomChans
+	hub        *Hub
+	clientRead chan messages.ClientMessage
+	room       gameroom.Chans
+	roomCode   string
+
+	//wrong passwords given on this connection, for logging in and for rooms
+	loginAttempts        int
+	roomPasswordAttempts int
+	//account is the logged in username, empty for guests
+	account string
 }
 
-func NewPlayer(conn *websocket.Conn, roomRequests chan RoomRequest) *Player {
+func NewPlayer(conn *websocket.Conn, hub *Hub) *Player {
 	p := Player{
-		conn:         conn,
-		roomRequests: roomRequests,
-		clientRead:   make(chan messages.ClientMessage),
+		conn:       conn,
+		hub:        hub,
+		clientRead: make(chan messages.ClientMessage),
 	}
 
 	p.notInRoom = PlayerStateNotInRoom{&p}
 	p.waitingRoom = PlayerStateWaitingRoom{&p}
 	p.inGameSelection = PlayerStateInGameSelection{&p}
 	p.inRoom = PlayerStateInRoom{&p}
+	p.postGame = PlayerStatePostGame{&p}
+	p.spectating = PlayerStateSpectating{&p}
+	p.searching = PlayerStateSearching{&p}
 
 	p.state = p.notInRoom
 
@@ -57,16 +85,49 @@ func NewPlayer(conn *websocket.Conn, roomRequests chan RoomRequest) *Player {
 func (p *Player) Run() {
 	go p.readPump()
 	defer log.Printf("Player %v goroutine exited\n", p.playerNumber)
+	defer func() {
+		//once the server is going away, a player with nothing left to do hangs up rather than linger
+		select {
+		case <-p.hub.goingAway:
+			p.signOff()
+		default:
+		}
+	}()
+	goingAway := p.hub.goingAway
 	for {
 		select {
+		case <-p.hub.stopped:
+			p.flushRoom()
+			return
+		case <-goingAway:
+			//said once, whatever the player is doing. Their room, if any, closes itself once drained
+			goingAway = nil
+			err := p.WriteToClient(messages.ServerMessage{
+				Type:        messages.ServerGoingAway,
+				DrainWindow: p.hub.roomOptions.DrainWindow,
+			})
+			if err != nil {
+				//the read side will notice the connection is gone and clean up as usual
+				log.Printf("Error while telling client the server is going away: %v\n", err)
+			}
 		case cm, ok := <-p.clientRead:
 			if !ok {
-				//client connection closed, handle like client gracefully quitting room
+				//client connection closed, the room decides whether to hold the seat or end the game
 				log.Println("Client connection closed, closing player.")
 				p.clientRead = nil
-				if p.room.playerToRoom != nil {
-					p.room.playerToRoom <- messages.ClientMessage{
-						Type: messages.ClientQuitRoom,
+				if _, ok := p.state.(PlayerStateSpectating); ok {
+					p.leaveAsSpectator()
+					return
+				}
+				if _, ok := p.state.(PlayerStateSearching); ok {
+					p.abandonSearch()
+					return
+				}
+				if p.room.PlayerToRoom != nil {
+					select {
+					case p.room.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}:
+					case <-time.After(disconnectSendTimeout):
+						log.Println("Room did not take disconnect, seat was likely resumed elsewhere.")
 					}
 				}
 				return
@@ -76,16 +137,23 @@ func (p *Player) Run() {
 				log.Printf("Error while handling client message: %v\n", err)
 				return
 			}
-		case rm, ok := <-p.room.roomToPlayer:
+		case rm, ok := <-p.room.RoomToPlayer:
 			if !ok {
 				//room closed - server error
-				//TODO keep player connection alive on game end
 				log.Println("Room closed, closing player.")
 				p.WriteToClient(messages.ServerMessage{
 					Type: messages.ServerRoomDisconnected,
 				})
 				return
 			}
+			if rm.Type == messages.ServerOpponentDisconnected || rm.Type == messages.ServerOpponentReconnected {
+				//the opponent's connection can come and go in any state
+				if err := p.WriteToClient(rm); err != nil {
+					log.Printf("Error while handling room message: %v\n", err)
+					return
+				}
+				continue
+			}
 			err := p.state.handleRoomMessage(rm)
 			if err != nil {
 				log.Printf("Error while handling room message: %v\n", err)
@@ -96,11 +164,15 @@ func (p *Player) Run() {
 }
 
 func (p *Player) readPump() {
+	stopHeartbeat := make(chan struct{})
 	defer func() {
 		log.Println("Shutting down player.")
+		close(stopHeartbeat)
 		p.conn.Close()
 		close(p.clientRead)
+		p.hub.players.Done()
 	}()
+	p.startHeartbeat(stopHeartbeat)
 
 	for {
 		clientMsg := messages.ClientMessage{}
@@ -115,6 +187,131 @@ func (p *Player) readPump() {
 	}
 }
 
+// leaveAsSpectator - Spectators are not read from by the room, so leaving goes through the hub.
+func (player *Player) leaveAsSpectator() {
+	go func(req gameroom.Request) {
+		player.hub.roomRequests <- req
+	}(gameroom.Request{Code: player.roomCode, Chans: player.room, Leave: true})
+
+	player.room = gameroom.Chans{}
+	player.setState(player.notInRoom)
+}
+
+// abandonSearch - Pulls a dropped player out of the quick match queue. The hub may have matched
+// them already, in which case the room is told they are gone like any other dropped player.
+func (player *Player) abandonSearch() {
+	player.hub.matchRequests <- matchRequest{chans: player.room, cancel: true}
+
+	select {
+	case rm := <-player.room.RoomToPlayer:
+		if rm.Type != messages.ServerRoomJoined {
+			return
+		}
+	case <-time.After(disconnectSendTimeout):
+		return
+	}
+	select {
+	case player.room.PlayerToRoom <- messages.ClientMessage{Type: messages.ClientDisconnected}:
+	case <-time.After(disconnectSendTimeout):
+		log.Println("Room did not take disconnect from matched player.")
+	}
+}
+
+// identify - Works out who a player entering a room is. A session token from an earlier login
+// makes them that account, and their username is their nickname unless they picked another.
+func (player *Player) identify(msg *messages.ClientMessage) {
+	if msg.SessionToken != "" && player.account == "" {
+		account, err := store.Resume(player.hub.accounts, msg.SessionToken)
+		if err == nil {
+			player.account = account.Username
+		}
+	}
+	if msg.Nickname == "" {
+		msg.Nickname = player.account
+	}
+}
+
+// logIn - Registers, logs in with a password, or picks up a session from an earlier login.
+func (player *Player) logIn(msg messages.ClientMessage) error {
+	var (
+		account store.Account
+		token   string
+		err     error
+	)
+	switch {
+	case msg.Type == messages.ClientRegister:
+		account, token, err = store.Register(player.hub.accounts, msg.Username, msg.Password)
+	case msg.SessionToken != "":
+		account, err = store.Resume(player.hub.accounts, msg.SessionToken)
+		token = msg.SessionToken
+	default:
+		account, token, err = store.Login(player.hub.accounts, msg.Username, msg.Password)
+	}
+
+	if errors.Is(err, store.ErrWrongPassword) {
+		player.loginAttempts++
+		if player.loginAttempts >= maxPasswordAttempts {
+			player.WriteToClient(messages.ServerMessage{
+				Type:         messages.ServerAuthFailed,
+				ErrorMessage: "too many wrong passwords",
+			})
+			player.conn.Close()
+			return fmt.Errorf("player used up their password attempts")
+		}
+	}
+	if err != nil {
+		return player.WriteToClient(messages.ServerMessage{
+			Type:         messages.ServerAuthFailed,
+			ErrorMessage: err.Error(),
+		})
+	}
+
+	log.Printf("Player logged in as %v", account.Username)
+	player.account = account.Username
+	return player.WriteToClient(messages.ServerMessage{
+		Type:         messages.ServerLoggedIn,
+		Account:      account.Info(),
+		SessionToken: token,
+	})
+}
+
+// sendGameHistory - Lists the logged in player's latest games. The turns are left out, a game is
+// fetched by ID to replay it.
+func (player *Player) sendGameHistory(limit int) error {
+	if player.account == "" {
+		return player.WriteToClient(messages.ServerMessage{
+			Type:         messages.ServerError,
+			ErrorMessage: "log in to see your games",
+		})
+	}
+
+	records, err := player.hub.accounts.RecentRecords(player.account, min(max(limit, 1), maxHistorySize))
+	if err != nil {
+		return player.WriteToClient(messages.ServerMessage{
+			Type:         messages.ServerError,
+			ErrorMessage: err.Error(),
+		})
+	}
+	for i := range records {
+		records[i].Turns = nil
+	}
+	return player.WriteToClient(messages.ServerMessage{
+		Type:    messages.ServerGameHistory,
+		Records: records,
+	})
+}
+
+// sendToRoom - Forwards a client message to the room. A room that handed this seat to a resumed
+// connection no longer reads from it, so the send gives up rather than hang the player.
+func (player *Player) sendToRoom(msg messages.ClientMessage) error {
+	select {
+	case player.room.PlayerToRoom <- msg:
+		return nil
+	case <-time.After(disconnectSendTimeout):
+		return errors.New("room is no longer reading from this player")
+	}
+}
+
 func (player *Player) setState(state PlayerState) {
 	player.state = state
 }
@@ -135,24 +332,109 @@ type PlayerStateNotInRoom struct {
 
 func (state PlayerStateNotInRoom) handleClientMessage(msg messages.ClientMessage) error {
 	switch msg.Type {
-	case messages.ClientJoinRoom:
-		chans := RoomChans{
-			roomToPlayer: make(chan messages.ServerMessage),
-			playerToRoom: make(chan messages.ClientMessage),
+	case messages.ClientJoinRoom, messages.ClientCreateRoom:
+		state.player.identify(&msg)
+		chans := gameroom.Chans{
+			RoomToPlayer: make(chan messages.ServerMessage, roomMessageBuffer),
+			PlayerToRoom: make(chan messages.ClientMessage),
+		}
+
+		state.player.room = chans
+		state.player.roomCode = msg.RoomCode
+
+		go func(chans gameroom.Chans) {
+			state.player.hub.roomRequests <- gameroom.Request{
+				Code:     msg.RoomCode,
+				Chans:    chans,
+				Public:   msg.Public,
+				Rated:    msg.Rated,
+				Password: msg.Password,
+				Nickname: msg.Nickname,
+				Account:  state.player.account,
+				Create:   msg.Type == messages.ClientCreateRoom,
+
+				TimeControl: msg.TimeControl,
+			}
+		}(chans)
+	case messages.ClientResume:
+		chans := gameroom.Chans{
+			RoomToPlayer: make(chan messages.ServerMessage, roomMessageBuffer),
+			PlayerToRoom: make(chan messages.ClientMessage),
 		}
 
 		state.player.room = chans
+		state.player.roomCode = msg.RoomCode
 
-		go func(chans RoomChans) {
-			state.player.roomRequests <- RoomRequest{
-				code:  msg.RoomCode,
-				chans: chans,
+		go func(chans gameroom.Chans) {
+			state.player.hub.roomRequests <- gameroom.Request{
+				Code:        msg.RoomCode,
+				Chans:       chans,
+				ResumeToken: msg.ResumeToken,
 			}
 		}(chans)
-		case messages.ClientQuitRoom:
-			state.player.WriteToClient(messages.ServerMessage{
-				Type: messages.ServerRoomClosed,
+		log.Printf("Player resuming seat in room: %v", msg.RoomCode)
+	case messages.ClientQuickMatch:
+		state.player.identify(&msg)
+		chans := gameroom.Chans{
+			RoomToPlayer: make(chan messages.ServerMessage, roomMessageBuffer),
+			PlayerToRoom: make(chan messages.ClientMessage),
+		}
+
+		state.player.room = chans
+		state.player.roomCode = ""
+		//sent in order with any cancel, so the hub never queues a search that was already called off
+		state.player.hub.matchRequests <- matchRequest{
+			gameType: msg.GameType,
+			nickname: msg.Nickname,
+			account:  state.player.account,
+			chans:    chans,
+		}
+		state.player.setState(state.player.searching)
+		log.Printf("Player searching for a %v match", msg.GameType)
+	case messages.ClientRegister, messages.ClientLogin:
+		return state.player.logIn(msg)
+	case messages.ClientLogout:
+		state.player.account = ""
+		if err := state.player.hub.accounts.DeleteSession(msg.SessionToken); err != nil {
+			log.Printf("Error ending session: %v", err)
+		}
+	case messages.ClientListRooms:
+		return state.player.WriteToClient(messages.ServerMessage{
+			Type:  messages.ServerRoomList,
+			Rooms: state.player.hub.ListRooms(),
+		})
+	case messages.ClientLeaderboard:
+		leaderboard, err := state.player.hub.accounts.Leaderboard(msg.GameType, leaderboardSize)
+		if err != nil {
+			return state.player.WriteToClient(messages.ServerMessage{
+				Type:         messages.ServerError,
+				ErrorMessage: err.Error(),
 			})
+		}
+		return state.player.WriteToClient(messages.ServerMessage{
+			Type:        messages.ServerLeaderboard,
+			GameType:    msg.GameType,
+			Leaderboard: leaderboard,
+		})
+	case messages.ClientGameHistory:
+		state.player.identify(&msg)
+		return state.player.sendGameHistory(msg.Limit)
+	case messages.ClientFetchGame:
+		record, err := state.player.hub.accounts.Record(msg.GameID)
+		if err != nil {
+			return state.player.WriteToClient(messages.ServerMessage{
+				Type:         messages.ServerError,
+				ErrorMessage: err.Error(),
+			})
+		}
+		return state.player.WriteToClient(messages.ServerMessage{
+			Type:   messages.ServerGameRecord,
+			Record: record,
+		})
+	case messages.ClientQuitRoom:
+		state.player.WriteToClient(messages.ServerMessage{
+			Type: messages.ServerRoomClosed,
+		})
 		log.Printf("Player waiting for room. Room code: %v", msg.RoomCode)
 	default:
 		return fmt.Errorf("unsupported message type while waiting for room: %v", msg.Type)
@@ -166,6 +448,7 @@ func (state PlayerStateNotInRoom) handleRoomMessage(msg messages.ServerMessage)
 	switch msg.Type {
 	case messages.ServerRoomJoined:
 		state.player.playerNumber = msg.PlayerNumber
+		state.player.roomCode = msg.RoomCode
 		err := state.player.WriteToClient(msg)
 		if err != nil {
 			//TODO handle shutting down clients
@@ -174,9 +457,46 @@ func (state PlayerStateNotInRoom) handleRoomMessage(msg messages.ServerMessage)
 
 		state.player.setState(state.player.waitingRoom)
 	case messages.ServerRoomUnavailable:
-		msg.ErrorMessage = "room is full"
-		state.player.WriteToClient(msg)
-		return fmt.Errorf("player tried to join full room")
+		//a mistyped code is not fatal, the player can try another
+		state.player.room = gameroom.Chans{}
+		return state.player.WriteToClient(msg)
+	case messages.ServerPasswordRequired:
+		state.player.room = gameroom.Chans{}
+		state.player.roomPasswordAttempts++
+		if state.player.roomPasswordAttempts >= maxPasswordAttempts {
+			msg.ErrorMessage = "too many wrong passwords"
+			state.player.WriteToClient(msg)
+			state.player.conn.Close()
+			return fmt.Errorf("player used up their password attempts")
+		}
+		return state.player.WriteToClient(msg)
+	case messages.ServerSpectating:
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.spectating)
+	case messages.ServerResumed:
+		state.player.playerNumber = msg.PlayerNumber
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+
+		switch msg.Phase {
+		case messages.RoomPhaseWaitingForOpponent:
+			state.player.setState(state.player.waitingRoom)
+		case messages.RoomPhaseGameSelection:
+			state.player.setState(state.player.inGameSelection)
+		case messages.RoomPhaseInGame:
+			state.player.setState(state.player.inRoom)
+		case messages.RoomPhasePostGame:
+			state.player.setState(state.player.postGame)
+		}
+	case messages.ServerResumeFailed:
+		//nothing to resume, but the connection can still be used to join a new room
+		state.player.room = gameroom.Chans{}
+		return state.player.WriteToClient(msg)
 	default:
 		return fmt.Errorf("unsupported message type while waiting for room: %v", msg.Type)
 	}
@@ -190,8 +510,12 @@ type PlayerStateWaitingRoom struct {
 
 func (state PlayerStateWaitingRoom) handleClientMessage(msg messages.ClientMessage) error {
 	switch msg.Type {
-	case messages.ClientQuitRoom:
-		state.player.room.playerToRoom <- msg
+	case messages.ClientQuitRoom, messages.ClientChat:
+		if err := state.player.sendToRoom(msg); err != nil {
+			return err
+		}
+	case messages.ClientEmote:
+		//sent from the end screen as the room moved on, and there is no game left to react to
 	default:
 		return fmt.Errorf("unsupported message type while waiting for room: %v", msg.Type)
 	}
@@ -209,9 +533,21 @@ func (state PlayerStateWaitingRoom) handleRoomMessage(msg messages.ServerMessage
 		if err != nil {
 			return err
 		}
+		state.player.setState(state.player.inGameSelection)
+	case messages.ServerGameStarted:
+		//quick match rooms go straight into the game
+		state.player.playerNumber = msg.PlayerNumber
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.inRoom)
+	case messages.ServerChat:
+		return state.player.WriteToClient(msg)
+	default:
+		state.player.setState(state.player.inGameSelection)
 	}
 
-	state.player.setState(state.player.inGameSelection)
 	return nil
 }
 
@@ -222,9 +558,15 @@ type PlayerStateInGameSelection struct {
 func (state PlayerStateInGameSelection) handleClientMessage(msg messages.ClientMessage) error {
 	switch msg.Type {
 	case messages.ClientQuitRoom:
-		state.player.room.playerToRoom <- msg
-	case messages.ClientSelectGameType:
-		state.player.room.playerToRoom <- msg
+		if err := state.player.sendToRoom(msg); err != nil {
+			return err
+		}
+	case messages.ClientSelectGameType, messages.ClientChat:
+		if err := state.player.sendToRoom(msg); err != nil {
+			return err
+		}
+	case messages.ClientEmote:
+		//sent from the end screen just as a new game vote passed, so there is no game left to react to
 	default:
 		return fmt.Errorf("unsupported message type while game selection: %v", msg.Type)
 	}
@@ -238,11 +580,14 @@ func (state PlayerStateInGameSelection) handleRoomMessage(msg messages.ServerMes
 		state.player.WriteToClient(msg)
 		return fmt.Errorf("client quit, closing room")
 	case messages.ServerGameStarted:
+		state.player.playerNumber = msg.PlayerNumber
 		err := state.player.WriteToClient(msg)
 		if err != nil {
 			return err
 		}
 		state.player.setState(state.player.inRoom)
+	case messages.ServerChat:
+		return state.player.WriteToClient(msg)
 	default:
 		return fmt.Errorf("unsupported message type while in game selection: %v", msg.Type)
 	}
@@ -256,8 +601,10 @@ type PlayerStateInRoom struct {
 
 func (state PlayerStateInRoom) handleClientMessage(msg messages.ClientMessage) error {
 	switch msg.Type {
-	case messages.ClientSendTurn, messages.ClientQuitRoom, messages.ClientConcede:
-		state.player.room.playerToRoom <- msg
+	case messages.ClientSendTurn, messages.ClientQuitRoom, messages.ClientConcede, messages.ClientMakeOffer, messages.ClientAnswerOffer, messages.ClientChat, messages.ClientEmote:
+		if err := state.player.sendToRoom(msg); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unsupported message type while in room: %v", msg.Type)
 
@@ -269,12 +616,15 @@ func (state PlayerStateInRoom) handleClientMessage(msg messages.ClientMessage) e
 func (state PlayerStateInRoom) handleRoomMessage(msg messages.ServerMessage) error {
 	switch msg.Type {
 	case messages.ServerGameFinished:
-		state.player.WriteToClient(msg)
-		state.player.setState(state.player.notInRoom)
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.postGame)
 	case messages.ServerRoomClosed:
 		state.player.WriteToClient(msg)
 		return fmt.Errorf("client quit, closing room")
-	case messages.ServerTurnResult, messages.ServerError:
+	case messages.ServerTurnResult, messages.ServerError, messages.ServerOffer, messages.ServerOfferAnswered, messages.ServerChat, messages.ServerEmote:
 		err := state.player.WriteToClient(msg)
 		if err != nil {
 			return err
@@ -285,3 +635,118 @@ func (state PlayerStateInRoom) handleRoomMessage(msg messages.ServerMessage) err
 
 	return nil
 }
+
+type PlayerStatePostGame struct {
+	player *Player
+}
+
+func (state PlayerStatePostGame) handleClientMessage(msg messages.ClientMessage) error {
+	switch msg.Type {
+	case messages.ClientRematchVote, messages.ClientQuitRoom, messages.ClientChat, messages.ClientEmote:
+		if err := state.player.sendToRoom(msg); err != nil {
+			return err
+		}
+	case messages.ClientMakeOffer, messages.ClientAnswerOffer:
+		//the game ended while the offer was on its way, so there is nothing left to agree to
+	default:
+		return fmt.Errorf("unsupported message type after game end: %v", msg.Type)
+	}
+
+	return nil
+}
+
+func (state PlayerStatePostGame) handleRoomMessage(msg messages.ServerMessage) error {
+	switch msg.Type {
+	case messages.ServerRematchVote, messages.ServerChat, messages.ServerEmote:
+		return state.player.WriteToClient(msg)
+	case messages.ServerGameStarted:
+		state.player.playerNumber = msg.PlayerNumber
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.inRoom)
+	case messages.ServerEnteredGameSelection:
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.inGameSelection)
+	case messages.ServerRoomClosed:
+		//the opponent left, but this connection can stay open for the next room
+		state.player.room = gameroom.Chans{}
+		state.player.setState(state.player.notInRoom)
+		return state.player.WriteToClient(msg)
+	default:
+		return fmt.Errorf("unsupported message type after game end: %v", msg.Type)
+	}
+
+	return nil
+}
+
+type PlayerStateSpectating struct {
+	player *Player
+}
+
+func (state PlayerStateSpectating) handleClientMessage(msg messages.ClientMessage) error {
+	switch msg.Type {
+	case messages.ClientQuitRoom:
+		state.player.leaveAsSpectator()
+		return state.player.WriteToClient(messages.ServerMessage{
+			Type: messages.ServerRoomClosed,
+		})
+	default:
+		return fmt.Errorf("unsupported message type while spectating: %v", msg.Type)
+	}
+}
+
+func (state PlayerStateSpectating) handleRoomMessage(msg messages.ServerMessage) error {
+	switch msg.Type {
+	case messages.ServerGameStarted, messages.ServerTurnResult, messages.ServerGameFinished, messages.ServerEnteredGameSelection:
+		return state.player.WriteToClient(msg)
+	case messages.ServerRoomClosed:
+		//the match is over, but this connection can stay open for the next room
+		state.player.room = gameroom.Chans{}
+		state.player.setState(state.player.notInRoom)
+		return state.player.WriteToClient(msg)
+	default:
+		return fmt.Errorf("unsupported message type while spectating: %v", msg.Type)
+	}
+}
+
+type PlayerStateSearching struct {
+	player *Player
+}
+
+func (state PlayerStateSearching) handleClientMessage(msg messages.ClientMessage) error {
+	switch msg.Type {
+	case messages.ClientCancelQuickMatch:
+		//the hub answers with SearchCancelled, or with a room if the match was already made
+		state.player.hub.matchRequests <- matchRequest{chans: state.player.room, cancel: true}
+	default:
+		return fmt.Errorf("unsupported message type while searching: %v", msg.Type)
+	}
+
+	return nil
+}
+
+func (state PlayerStateSearching) handleRoomMessage(msg messages.ServerMessage) error {
+	switch msg.Type {
+	case messages.ServerRoomJoined:
+		state.player.playerNumber = msg.PlayerNumber
+		state.player.roomCode = msg.RoomCode
+		err := state.player.WriteToClient(msg)
+		if err != nil {
+			return err
+		}
+		state.player.setState(state.player.waitingRoom)
+	case messages.ServerSearchCancelled, messages.ServerError:
+		state.player.room = gameroom.Chans{}
+		state.player.setState(state.player.notInRoom)
+		return state.player.WriteToClient(msg)
+	default:
+		return fmt.Errorf("unsupported message type while searching: %v", msg.Type)
+	}
+
+	return nil
+}
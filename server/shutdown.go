@@ -0,0 +1,51 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
+)
+
+// how long a player may take to pass on its last messages and hang up once the hub has stopped
+const signOffTimeout = 2 * time.Second
+
+// waitForPlayers - Waits for every connection to close after the hub has stopped. Reports false if
+// some were still open when the timeout ran out.
+func (h *Hub) waitForPlayers(timeout time.Duration) bool {
+	closed := make(chan struct{})
+	go func() {
+		h.players.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// flushRoom - Writes out whatever the room left queued for the player, such as the room closing
+// for the shutdown, without waiting on a room that has nothing more to say.
+func (player *Player) flushRoom() {
+	for {
+		select {
+		case rm, ok := <-player.room.RoomToPlayer:
+			if !ok {
+				return
+			}
+			player.WriteToClient(rm)
+		default:
+			return
+		}
+	}
+}
+
+// signOff - Hangs up with a going away close frame, so the client knows the server meant to go.
+// WriteControl is safe to call alongside the heartbeat's pings.
+func (player *Player) signOff() {
+	closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, gameroom.ErrShuttingDown.Error())
+	player.conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(signOffTimeout))
+	player.conn.Close()
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 16
+	MinPasswordLength = 8
+	saltLength        = 16
+	keyLength         = 32
+)
+
+// OWASP's recommendation for PBKDF2-HMAC-SHA256, tests turn it down to keep them quick
+var hashIterations = 600_000
+
+var (
+	ErrInvalidUsername = errors.New("invalid username")
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrWrongPassword   = errors.New("wrong username or password")
+)
+
+// Account - A registered player. Only a salted hash of the password is ever kept.
+type Account struct {
+	Username   string                                 `json:"username"`
+	Salt       []byte                                 `json:"salt"`
+	Hash       []byte                                 `json:"hash"`
+	Iterations int                                    `json:"iterations"`
+	Created    time.Time                              `json:"created"`
+	Stats      map[game.GameType]messages.PlayerStats `json:"stats"`
+}
+
+// Info - The parts of an account that are safe to send to its player.
+func (account Account) Info() messages.AccountInfo {
+	return messages.AccountInfo{Username: account.Username, Stats: account.Stats}
+}
+
+func (account Account) checkPassword(password string) bool {
+	hash, err := pbkdf2.Key(sha256.New, password, account.Salt, account.Iterations, len(account.Hash))
+	return err == nil && subtle.ConstantTimeCompare(hash, account.Hash) == 1
+}
+
+// ValidateUsername - Usernames double as nicknames, so they stick to characters any terminal can show.
+func ValidateUsername(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length < MinUsernameLength || length > MaxUsernameLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalidUsername, MinUsernameLength, MaxUsernameLength)
+	}
+	for _, char := range username {
+		isLetter := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && !isDigit && char != '_' && char != '-' {
+			return fmt.Errorf("%w: only letters, digits, _ and - are allowed", ErrInvalidUsername)
+		}
+	}
+	return nil
+}
+
+// accountKey - Usernames are unique regardless of case.
+func accountKey(username string) string {
+	return strings.ToLower(username)
+}
+
+// Register - Creates an account and logs it in, returning a session token for the client to keep.
+func Register(store Store, username, password string) (Account, string, error) {
+	if err := ValidateUsername(username); err != nil {
+		return Account{}, "", err
+	}
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return Account{}, "", fmt.Errorf("%w: must be at least %d characters", ErrInvalidPassword, MinPasswordLength)
+	}
+
+	account := Account{
+		Username:   username,
+		Salt:       make([]byte, saltLength),
+		Iterations: hashIterations,
+		Created:    time.Now(),
+		Stats:      map[game.GameType]messages.PlayerStats{},
+	}
+	rand.Read(account.Salt)
+	hash, err := pbkdf2.Key(sha256.New, password, account.Salt, account.Iterations, keyLength)
+	if err != nil {
+		return Account{}, "", err
+	}
+	account.Hash = hash
+
+	if err := store.CreateAccount(account); err != nil {
+		return Account{}, "", err
+	}
+	token, err := store.CreateSession(account.Username)
+	return account, token, err
+}
+
+// Login - Checks a password and starts a session. Unknown usernames and wrong passwords give the
+// same error, so logging in cannot be used to find out who has an account.
+func Login(store Store, username, password string) (Account, string, error) {
+	account, err := store.Account(username)
+	if errors.Is(err, ErrAccountNotFound) {
+		return Account{}, "", ErrWrongPassword
+	}
+	if err != nil {
+		return Account{}, "", err
+	}
+	if !account.checkPassword(password) {
+		return Account{}, "", ErrWrongPassword
+	}
+
+	token, err := store.CreateSession(account.Username)
+	return account, token, err
+}
+
+// Resume - Looks up the account behind a session token from an earlier login.
+func Resume(store Store, token string) (Account, error) {
+	username, err := store.SessionAccount(token)
+	if err != nil {
+		return Account{}, err
+	}
+	return store.Account(username)
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func init() {
+	//the real iteration count takes most of a second per hash
+	hashIterations = 1_000
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registered, token, err := Register(store, "Ada", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(registered.Hash) == "correct horse" || len(registered.Salt) != saltLength {
+		t.Error("the password should only be kept as a salted hash")
+	}
+	if resumed, err := Resume(store, token); err != nil || resumed.Username != "Ada" {
+		t.Errorf("expected the session to belong to Ada, got %q and %v", resumed.Username, err)
+	}
+
+	//usernames are not case sensitive, but keep the case they were registered with
+	account, _, err := Login(store, "ada", "correct horse")
+	if err != nil || account.Username != "Ada" {
+		t.Errorf("expected to log in as Ada, got %q and %v", account.Username, err)
+	}
+	if _, _, err := Login(store, "Ada", "wrong horse"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected a wrong password to be rejected, got %v", err)
+	}
+	if _, _, err := Login(store, "Grace", "correct horse"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("expected an unknown user to look like a wrong password, got %v", err)
+	}
+	if _, _, err := Register(store, "ADA", "another password"); !errors.Is(err, ErrAccountExists) {
+		t.Errorf("expected the username to be taken, got %v", err)
+	}
+}
+
+func TestRegisterValidatesCredentials(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		username string
+		password string
+		expected error
+	}{
+		{"Al", "long enough", ErrInvalidUsername},
+		{"this-name-is-far-too-long", "long enough", ErrInvalidUsername},
+		{"bad name", "long enough", ErrInvalidUsername},
+		{"\x1b[31mred", "long enough", ErrInvalidUsername},
+		{"Grace", "short", ErrInvalidPassword},
+	}
+	for _, test := range tests {
+		if _, _, err := Register(store, test.username, test.password); !errors.Is(err, test.expected) {
+			t.Errorf("registering %q with %q: expected %v, got %v", test.username, test.password, test.expected, err)
+		}
+	}
+}
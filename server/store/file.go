@@ -0,0 +1,293 @@
+package store
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	accountsFile = "accounts.json"
+	//each game record gets a file of its own in here, named by its ID
+	recordsDir = "games"
+	//record IDs are this many random bytes, hex encoded
+	recordIDLength = 8
+)
+
+// fileData - Everything the file store keeps, written out whole on every change.
+type fileData struct {
+	//keyed by accountKey
+	Accounts map[string]Account `json:"accounts"`
+	//session token to username
+	Sessions map[string]string `json:"sessions"`
+	//keyed by accountKey, the IDs of every game the account played, oldest first
+	History map[string][]string `json:"history"`
+}
+
+// FileStore - Keeps accounts in a JSON file in the data directory. The whole file is held in
+// memory and rewritten on every change, which is plenty for a server of friends. Game records are
+// only read when asked for, so they are kept a file each instead.
+type FileStore struct {
+	path       string
+	recordsDir string
+	mu         sync.Mutex
+	data       fileData
+}
+
+func OpenFileStore(dataDir string) (*FileStore, error) {
+	store := &FileStore{
+		path:       filepath.Join(dataDir, accountsFile),
+		recordsDir: filepath.Join(dataDir, recordsDir),
+		data:       fileData{Accounts: map[string]Account{}, Sessions: map[string]string{}, History: map[string][]string{}},
+	}
+	if err := os.MkdirAll(store.recordsDir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating data directory: %w", err)
+	}
+
+	contents, err := os.ReadFile(store.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading accounts: %w", err)
+	}
+	if err := json.Unmarshal(contents, &store.data); err != nil {
+		return nil, fmt.Errorf("error parsing %v: %w", store.path, err)
+	}
+	//files from before game records were kept have no history
+	if store.data.History == nil {
+		store.data.History = map[string][]string{}
+	}
+	return store, nil
+}
+
+// save - Rewrites the accounts file. Callers must hold mu.
+func (store *FileStore) save() error {
+	contents, err := json.Marshal(store.data)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(store.path, contents); err != nil {
+		return fmt.Errorf("error writing accounts: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic - Writes to a temporary file first, so a crash mid-write cannot leave half a file.
+func writeFileAtomic(path string, contents []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, contents, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (store *FileStore) CreateAccount(account Account) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key := accountKey(account.Username)
+	if _, ok := store.data.Accounts[key]; ok {
+		return ErrAccountExists
+	}
+	store.data.Accounts[key] = account
+	return store.save()
+}
+
+func (store *FileStore) Account(username string) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	account, ok := store.data.Accounts[accountKey(username)]
+	if !ok {
+		return Account{}, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (store *FileStore) RecordGame(gameType game.GameType, usernames [2]string, results [2]messages.GameResult, rated bool) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var (
+		accounts [2]Account
+		found    [2]bool
+	)
+	for i, username := range usernames {
+		if username == "" {
+			continue
+		}
+		account, ok := store.data.Accounts[accountKey(username)]
+		if !ok {
+			return ErrAccountNotFound
+		}
+		accounts[i], found[i] = account, true
+	}
+
+	//a rating only means something against another account, and never against yourself
+	rated = rated && found[0] && found[1] && accountKey(usernames[0]) != accountKey(usernames[1])
+	var ratings [2]int
+	if rated {
+		ratings = updateRatings([2]int{accounts[0].Rating(gameType), accounts[1].Rating(gameType)}, results)
+	}
+
+	for i, account := range accounts {
+		if !found[i] {
+			continue
+		}
+		if account.Stats == nil {
+			account.Stats = map[game.GameType]messages.PlayerStats{}
+		}
+		stats := account.Stats[gameType]
+		switch results[i] {
+		case messages.GameResultPlayerWin:
+			stats.Wins++
+		case messages.GameResultPlayerLose:
+			stats.Losses++
+		case messages.GameResultDraw:
+			stats.Draws++
+		}
+		if rated {
+			stats.Rating = ratings[i]
+		}
+		account.Stats[gameType] = stats
+		store.data.Accounts[accountKey(account.Username)] = account
+	}
+	return store.save()
+}
+
+func (store *FileStore) Leaderboard(gameType game.GameType, limit int) ([]messages.LeaderboardEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var entries []messages.LeaderboardEntry
+	for _, account := range store.data.Accounts {
+		stats, ok := account.Stats[gameType]
+		if !ok || stats.Rating == 0 {
+			continue
+		}
+		entries = append(entries, messages.LeaderboardEntry{Username: account.Username, Stats: stats})
+	}
+	slices.SortFunc(entries, func(a, b messages.LeaderboardEntry) int {
+		if byRating := cmp.Compare(b.Stats.Rating, a.Stats.Rating); byRating != 0 {
+			return byRating
+		}
+		return cmp.Compare(accountKey(a.Username), accountKey(b.Username))
+	})
+	return entries[:min(limit, len(entries))], nil
+}
+
+func (store *FileStore) CreateSession(username string) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	token := make([]byte, 32)
+	rand.Read(token)
+	tokenStr := hex.EncodeToString(token)
+	store.data.Sessions[tokenStr] = username
+	return tokenStr, store.save()
+}
+
+func (store *FileStore) SessionAccount(token string) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	username, ok := store.data.Sessions[token]
+	if !ok {
+		return "", ErrSessionNotFound
+	}
+	return username, nil
+}
+
+func (store *FileStore) DeleteSession(token string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.data.Sessions, token)
+	return store.save()
+}
+
+func (store *FileStore) SaveRecord(record messages.GameRecord) (string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	id := make([]byte, recordIDLength)
+	rand.Read(id)
+	record.ID = hex.EncodeToString(id)
+
+	contents, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(store.recordPath(record.ID), contents); err != nil {
+		return "", fmt.Errorf("error writing game record: %w", err)
+	}
+
+	listed := false
+	for i, username := range record.Accounts {
+		//someone playing themselves only needs the game listed once
+		if username == "" || (i == 1 && accountKey(username) == accountKey(record.Accounts[0])) {
+			continue
+		}
+		key := accountKey(username)
+		store.data.History[key] = append(store.data.History[key], record.ID)
+		listed = true
+	}
+	if !listed {
+		return record.ID, nil
+	}
+	return record.ID, store.save()
+}
+
+func (store *FileStore) Record(id string) (messages.GameRecord, error) {
+	//IDs come from players, so anything that is not one of ours never gets near the file system
+	if decoded, err := hex.DecodeString(id); err != nil || len(decoded) != recordIDLength {
+		return messages.GameRecord{}, ErrRecordNotFound
+	}
+
+	contents, err := os.ReadFile(store.recordPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return messages.GameRecord{}, ErrRecordNotFound
+	}
+	if err != nil {
+		return messages.GameRecord{}, fmt.Errorf("error reading game record: %w", err)
+	}
+	var record messages.GameRecord
+	if err := json.Unmarshal(contents, &record); err != nil {
+		return messages.GameRecord{}, fmt.Errorf("error parsing game record %v: %w", id, err)
+	}
+	return record, nil
+}
+
+func (store *FileStore) RecentRecords(username string, limit int) ([]messages.GameRecord, error) {
+	store.mu.Lock()
+	history := store.data.History[accountKey(username)]
+	ids := slices.Clone(history[len(history)-min(max(limit, 0), len(history)):])
+	store.mu.Unlock()
+
+	slices.Reverse(ids)
+	records := make([]messages.GameRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := store.Record(id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (store *FileStore) recordPath(id string) string {
+	return filepath.Join(store.recordsDir, id+".json")
+}
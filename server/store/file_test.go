@@ -0,0 +1,148 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+	"github.com/wbarthol/ascii-arcade-2/internal/vector"
+)
+
+func TestFileStorePersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := Register(store, "Ada", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.RecordGame(game.GameTypeCheckers, [2]string{"Ada", ""}, [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose}, true)
+	store.RecordGame(game.GameTypeCheckers, [2]string{"", "ada"}, [2]messages.GameResult{messages.GameResultDraw, messages.GameResultDraw}, true)
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := Resume(reopened, token)
+	if err != nil {
+		t.Fatalf("expected the session to survive a restart, got %v", err)
+	}
+	expected := messages.PlayerStats{Wins: 1, Draws: 1}
+	if stats := account.Stats[game.GameTypeCheckers]; stats != expected {
+		t.Errorf("expected checkers stats %+v, got %+v", expected, stats)
+	}
+	if _, _, err := Login(reopened, "Ada", "correct horse"); err != nil {
+		t.Errorf("expected the password to still work, got %v", err)
+	}
+}
+
+func TestFileStoreDeleteSession(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := Register(store, "Ada", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteSession(token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resume(store, token); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected the session to be gone, got %v", err)
+	}
+}
+
+func TestFileStoreRatesGamesBetweenAccounts(t *testing.T) {
+	store, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"Ada", "Grace", "Linus"} {
+		if _, _, err := Register(store, username, "correct horse"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	win := [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose}
+
+	store.RecordGame(game.GameTypeCheckers, [2]string{"Ada", "Grace"}, win, true)
+	//unrated rooms, games against guests and games against yourself only count toward stats
+	store.RecordGame(game.GameTypeCheckers, [2]string{"Linus", "Ada"}, win, false)
+	store.RecordGame(game.GameTypeCheckers, [2]string{"Linus", ""}, win, true)
+	store.RecordGame(game.GameTypeCheckers, [2]string{"Linus", "linus"}, win, true)
+
+	leaderboard, err := store.Leaderboard(game.GameTypeCheckers, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []messages.LeaderboardEntry{
+		{Username: "Ada", Stats: messages.PlayerStats{Wins: 1, Losses: 1, Rating: 1216}},
+		{Username: "Grace", Stats: messages.PlayerStats{Losses: 1, Rating: 1184}},
+	}
+	if !slices.Equal(leaderboard, expected) {
+		t.Errorf("expected leaderboard %+v, got %+v", expected, leaderboard)
+	}
+
+	if leaderboard, _ := store.Leaderboard(game.GameTypeCheckers, 1); len(leaderboard) != 1 {
+		t.Errorf("expected the leaderboard to be cut to 1 entry, got %d", len(leaderboard))
+	}
+}
+
+func TestFileStoreKeepsGameRecords(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Register(store, "Ada", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, opponent := range []string{"first", "second", "third"} {
+		id, err := store.SaveRecord(messages.GameRecord{
+			GameType: game.GameTypeTicTacToe,
+			Players:  [2]string{"Ada", opponent},
+			Accounts: [2]string{"Ada", ""},
+			Turns: []messages.RecordedTurn{{
+				PlayerNumber: 1,
+				Turn:         messages.NewGameTurnWrapper(game.TicTacToeTurn{Coords: vector.NewVector(1, 1)}),
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := reopened.RecentRecords("ada", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].ID != ids[2] || recent[1].ID != ids[1] {
+		t.Fatalf("expected the last two games newest first, got %+v", recent)
+	}
+
+	record, err := reopened.Record(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Players[1] != "first" || len(record.Turns) != 1 || record.Turns[0].Turn.TicTacToeTurn.Coords != vector.NewVector(1, 1) {
+		t.Errorf("expected the first game back as saved, got %+v", record)
+	}
+
+	for _, id := range []string{"", "../accounts", "0123456789abcdef0"} {
+		if _, err := reopened.Record(id); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("expected no game for ID %q, got %v", id, err)
+		}
+	}
+}
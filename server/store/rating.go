@@ -0,0 +1,52 @@
+package store
+
+import (
+	"math"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+const (
+	//where every player starts at each game type
+	DefaultRating = 1200
+	//the most one game can move a rating
+	ratingK = 32
+)
+
+// Rating - The account's Elo rating at a game type, the default if they have not played it rated.
+func (account Account) Rating(gameType game.GameType) int {
+	return currentRating(account.Stats[gameType])
+}
+
+func currentRating(stats messages.PlayerStats) int {
+	if stats.Rating == 0 {
+		return DefaultRating
+	}
+	return stats.Rating
+}
+
+// updateRatings - Elo. Each player gains or loses by how much better or worse they did than their
+// rating against the other's predicted.
+func updateRatings(ratings [2]int, results [2]messages.GameResult) [2]int {
+	expected := 1 / (1 + math.Pow(10, float64(ratings[1]-ratings[0])/400))
+	expectedScores := [2]float64{expected, 1 - expected}
+
+	var updated [2]int
+	for i := range ratings {
+		change := ratingK * (score(results[i]) - expectedScores[i])
+		updated[i] = ratings[i] + int(math.Round(change))
+	}
+	return updated
+}
+
+func score(result messages.GameResult) float64 {
+	switch result {
+	case messages.GameResultPlayerWin:
+		return 1
+	case messages.GameResultDraw:
+		return 0.5
+	default:
+		return 0
+	}
+}
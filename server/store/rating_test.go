@@ -0,0 +1,31 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+func TestUpdateRatings(t *testing.T) {
+	win := [2]messages.GameResult{messages.GameResultPlayerWin, messages.GameResultPlayerLose}
+	draw := [2]messages.GameResult{messages.GameResultDraw, messages.GameResultDraw}
+
+	tests := []struct {
+		name     string
+		ratings  [2]int
+		results  [2]messages.GameResult
+		expected [2]int
+	}{
+		{"even players, win", [2]int{1200, 1200}, win, [2]int{1216, 1184}},
+		{"even players, draw", [2]int{1200, 1200}, draw, [2]int{1200, 1200}},
+		{"favourite wins", [2]int{1600, 1200}, win, [2]int{1603, 1197}},
+		{"underdog draws", [2]int{1200, 1600}, draw, [2]int{1213, 1587}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if updated := updateRatings(test.ratings, test.results); updated != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, updated)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+var (
+	ErrAccountExists   = errors.New("that username is taken")
+	ErrAccountNotFound = errors.New("no account with that username")
+	ErrSessionNotFound = errors.New("session has expired, log in again")
+	ErrRecordNotFound  = errors.New("no game with that ID")
+)
+
+// Store - Keeps player accounts between server runs. Implementations must be safe to use from
+// every player and room goroutine at once.
+type Store interface {
+	CreateAccount(account Account) error
+	Account(username string) (Account, error)
+	//RecordGame adds a finished game to the stats of each logged in player, usernames are empty for
+	//guests. A rated game between two accounts also moves their ratings.
+	RecordGame(gameType game.GameType, usernames [2]string, results [2]messages.GameResult, rated bool) error
+	//Leaderboard lists the highest rated players at a game type, best first
+	Leaderboard(gameType game.GameType, limit int) ([]messages.LeaderboardEntry, error)
+
+	//SaveRecord keeps a finished game under a new ID, and lists it in the history of each account
+	//that played it
+	SaveRecord(record messages.GameRecord) (id string, err error)
+	Record(id string) (messages.GameRecord, error)
+	//RecentRecords lists the account's latest games, newest first
+	RecentRecords(username string, limit int) ([]messages.GameRecord, error)
+
+	//sessions let a client stay logged in across connections without keeping the password
+	CreateSession(username string) (token string, err error)
+	SessionAccount(token string) (username string, err error)
+	DeleteSession(token string) error
+}
@@ -4,11 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/ai"
 	"github.com/wbarthol/ascii-arcade-2/internal/game"
+	"github.com/wbarthol/ascii-arcade-2/internal/gameroom"
 	"github.com/wbarthol/ascii-arcade-2/internal/messages"
 	"github.com/wbarthol/ascii-arcade-2/internal/vector"
 )
@@ -26,6 +31,11 @@ type ServerMsg struct {
 	serverClosed bool
 }
 
+// ConnectionStatusMsg - The driver lost its connection and is trying to get it back, or has just got it back.
+type ConnectionStatusMsg struct {
+	reconnecting bool
+}
+
 type SentClientMsg struct{}
 
 type ErrMsg struct {
@@ -43,25 +53,48 @@ type Session struct {
 
 	waitingForServerResponse bool
 	errMsg                   string
+	reconnecting             bool
+	opponentAway             bool
+	//goingAway is set once the server says it is shutting down, giving games drainWindow to finish
+	goingAway   bool
+	drainWindow time.Duration
 
 	playerNumber int
 	playerTurn   int
+	hotSeat      bool
+	playerNames  [2]string
+	config       Config
+	clocks       gameClocks
+	chat         chatPane
+	emote        emoteBanner
+	//latency is the round trip to the server, zero when there is no server to measure
+	latency time.Duration
+	//clockTicking is set while a ClockTickMsg is on its way, so only one is ever pending
+	clockTicking bool
 
 	gameType   game.GameType
 	game       game.Game
 	gameResult messages.GameResult
+	//replay is the game the replay screen opens on
+	replay messages.GameRecord
 
 	driverToSession chan messages.ServerMessage
-	wsDriver        *WSDriver
+	driverStatus    chan bool
+	driverLatency   chan time.Duration
+	driver          Driver
 	serverUrl       string
 }
 
-func NewSession(serverUrl string) Session {
+func NewSession(serverUrl string, config Config) Session {
 	session := Session{
 		serverUrl:       serverUrl,
+		config:          config,
 		driverToSession: make(chan messages.ServerMessage),
+		driverStatus:    make(chan bool),
+		driverLatency:   make(chan time.Duration),
+		chat:            newChatPane(),
 	}
-	session.state = NewSessionStateInMenu()
+	session.state = NewSessionStateInMenu(config)
 
 	return session
 }
@@ -71,22 +104,57 @@ func (session Session) StartWS(url string) (Session, error) {
 	if err != nil {
 		return session, fmt.Errorf("error starting WS: %w", err)
 	}
-	session.wsDriver = wsDriver
+	session.driver = wsDriver
 	session.driverToSession = wsDriver.driverToSession
-	go session.wsDriver.Run()
+	go session.driver.Run()
 	return session, nil
 }
 
+// StartLocalGame - Runs the room in this process with a bot in the second seat, no server needed.
+func (session Session) StartLocalGame(difficulty ai.Difficulty) Session {
+	localDriver := NewLocalDriver(&session, difficulty)
+	session.driver = localDriver
+	session.driverToSession = localDriver.driverToSession
+	go session.driver.Run()
+	return session
+}
+
+// StartLocalGameFrom - Like StartLocalGame, but the game is checkers from a set up position, given as a FEN.
+func (session Session) StartLocalGameFrom(difficulty ai.Difficulty, setup string) Session {
+	localDriver := NewLocalDriver(&session, difficulty)
+	localDriver.setup = setup
+	session.driver = localDriver
+	session.driverToSession = localDriver.driverToSession
+	go session.driver.Run()
+	return session
+}
+
+// StartHotSeatGame - Runs the room in this process with both seats played from this terminal.
+func (session Session) StartHotSeatGame() Session {
+	hotSeatDriver := NewHotSeatDriver(&session)
+	session.driver = hotSeatDriver
+	session.driverToSession = hotSeatDriver.driverToSession
+	session.hotSeat = true
+	go session.driver.Run()
+	return session
+}
+
 func (session Session) ListenToServer() tea.Cmd {
 	//Decoupling this from WSDriver with singleplayer in mind
 	return func() tea.Msg {
-		msg, ok := <-session.driverToSession
-		if !ok {
-			if session.state.GetType() != SessionStateTypeInMenu {
-				return ServerMsg{msg: msg, serverClosed: true}
+		select {
+		case msg, ok := <-session.driverToSession:
+			if !ok {
+				if session.state.GetType() != SessionStateTypeInMenu {
+					return ServerMsg{msg: msg, serverClosed: true}
+				}
 			}
+			return ServerMsg{msg: msg}
+		case reconnecting := <-session.driverStatus:
+			return ConnectionStatusMsg{reconnecting}
+		case latency := <-session.driverLatency:
+			return LatencyMsg{latency}
 		}
-		return ServerMsg{msg: msg}
 	}
 }
 
@@ -102,26 +170,80 @@ func (session Session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return session, tea.Quit
 		default:
+			if chatEnabled(session.state.GetType()) {
+				if handled, cmd := session.chat.handleKey(msg, session); handled {
+					return session, cmd
+				}
+			}
+			//hot seat players share a screen, so there is nobody to react to
+			if emotesEnabled(session.state.GetType()) && !session.hotSeat {
+				var handled bool
+				var cmd tea.Cmd
+				if session, handled, cmd = session.handleEmoteKey(msg); handled {
+					return session, cmd
+				}
+			}
 			//TODO if waitingForServerResponse, input should be rejected and error msg sent to client
 			return session.state.HandleUserInput(msg, session)
 		}
 	case ServerMsg:
 		session.waitingForServerResponse = false
+		before, wasYourTurn := session.state.GetType(), session.isYourTurn()
 		if msg.serverClosed {
-			//TODO Notify client the server closed
-			session = session.setState(SessionStateTypeInMenu)
+			//a driver closed on purpose has already sent the session back to the menu, or on to a replay
+			stateType := session.state.GetType()
+			if stateType != SessionStateTypeInMenu && stateType != SessionStateTypeReplay {
+				session = session.setState(SessionStateTypeInMenu)
+				session.errMsg = "Lost connection to the server."
+			}
 		} else {
 			var err error
-			session, err = session.state.handleServerMessage(session, msg.msg)
+			session, err = session.handleServerMessage(msg.msg)
 			if err != nil {
 				session.errMsg = err.Error()
 			}
 		}
+		cmds := []tea.Cmd{session.ListenToServer()}
+		if body := session.notification(before, wasYourTurn); body != "" {
+			cmds = append(cmds, session.notify(body))
+		}
+		if session.clocks.running != 0 && !session.clockTicking {
+			session.clockTicking = true
+			cmds = append(cmds, clockTick())
+		}
+		if !msg.serverClosed && msg.msg.Type == messages.ServerEmote {
+			var expire tea.Cmd
+			session, expire = session.showEmote(msg.msg)
+			cmds = append(cmds, expire)
+		}
+		return session, tea.Batch(cmds...)
+	case ConnectionStatusMsg:
+		session.reconnecting = msg.reconnecting
+		return session, session.ListenToServer()
+	case LatencyMsg:
+		session.latency = msg.latency
+		if inGame, ok := session.state.(*SessionStateInGame); ok {
+			inGame.latency = msg.latency
+		}
 		return session, session.ListenToServer()
 	case SentClientMsg:
 		session.waitingForServerResponse = true
 	case ErrMsg:
 		session.errMsg = msg.Error()
+	case ReplayTickMsg:
+		if replay, ok := session.state.(*SessionStateReplay); ok {
+			return session, replay.tick(msg)
+		}
+	case EmoteExpiredMsg:
+		if msg.id == session.emote.id {
+			session.emote.text = ""
+		}
+	case ClockTickMsg:
+		//redrawing is all a tick is for, and the redraw follows any message
+		if session.clocks.running != 0 {
+			return session, clockTick()
+		}
+		session.clockTicking = false
 	}
 
 	return session, nil
@@ -130,7 +252,35 @@ func (session Session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (session Session) View() string {
 	content := session.state.GetDisplayString()
 
+	bannerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#1C1C1E")).
+		Background(lipgloss.Color("#FF9500")).
+		Padding(0, 1)
+
 	var parts []string
+	if session.reconnecting {
+		parts = append(parts, bannerStyle.Render("⟳ Connection lost, reconnecting…"))
+	} else if session.opponentAway {
+		parts = append(parts, bannerStyle.Render("Opponent disconnected, holding their seat…"))
+	}
+	if session.goingAway {
+		parts = append(parts, bannerStyle.Background(lipgloss.Color("#FF3B30")).Foreground(lipgloss.Color("#FAFAFA")).
+			Render(fmt.Sprintf("⚠ The server is shutting down, games in progress have %v to finish", session.drainWindow)))
+	}
+	if session.emote.text != "" {
+		parts = append(parts, bannerStyle.Background(lipgloss.Color("#7D56F4")).Foreground(lipgloss.Color("#FAFAFA")).Render(session.emote.text))
+	}
+	if emotesEnabled(session.state.GetType()) && !session.hotSeat {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, emoteControls(session.config.MuteEmotes))
+	}
+	if chatEnabled(session.state.GetType()) {
+		if session.chat.open {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, content, " ", session.chat.render())
+		} else {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, session.chat.render())
+		}
+	}
 	parts = append(parts, content)
 	if session.errMsg != "" {
 		errorStyle := lipgloss.NewStyle().
@@ -154,6 +304,89 @@ func (session Session) SendMsgToServer(msg messages.ClientMessage) tea.Cmd {
 	}
 }
 
+// handleServerMessage - Connection messages can arrive in any state, so they are handled here
+// before the rest are passed on to the current state.
+func (session Session) handleServerMessage(msg messages.ServerMessage) (Session, error) {
+	if msg.PlayerNames != ([2]string{}) {
+		session.playerNames = msg.PlayerNames
+	}
+	//an untimed game's messages still clear the last game's clocks
+	switch {
+	case msg.TimeControl.IsTimed(), msg.Type == messages.ServerGameStarted, msg.Type == messages.ServerResumed, msg.Type == messages.ServerSpectating:
+		session.clocks = session.clocks.update(msg)
+	}
+	session, err := session.routeServerMessage(msg)
+	if named, ok := session.state.(interface{ setPlayerNames([2]string) }); ok {
+		named.setPlayerNames(session.playerNames)
+	}
+	if timed, ok := session.state.(interface{ setClocks(gameClocks) }); ok {
+		timed.setClocks(session.clocks)
+	}
+	if inGame, ok := session.state.(*SessionStateInGame); ok {
+		inGame.latency = session.latency
+	}
+	return session, err
+}
+
+func (session Session) routeServerMessage(msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerResumed:
+		return session.resync(msg), nil
+	case messages.ServerResumeFailed:
+		session = session.setState(SessionStateTypeInMenu)
+		session.errMsg = "Could not rejoin the room: " + msg.ErrorMessage
+		return session, nil
+	case messages.ServerOpponentDisconnected:
+		session.opponentAway = true
+		return session, nil
+	case messages.ServerOpponentReconnected:
+		session.opponentAway = false
+		return session, nil
+	case messages.ServerGoingAway:
+		session.goingAway = true
+		session.drainWindow = msg.DrainWindow
+		return session, nil
+	case messages.ServerEmote:
+		//the banner goes up in Update, which can start the timer to take it down
+		return session, nil
+	case messages.ServerChat:
+		//a message the server would not pass on comes back to us with the reason
+		if msg.ErrorMessage != "" {
+			session.errMsg = "Chat: " + msg.ErrorMessage
+			return session, nil
+		}
+		from := playerNames(session.playerNames).name(msg.PlayerNumber)
+		session.chat.add(from, msg.Text, msg.PlayerNumber == session.playerNumber && !session.hotSeat)
+		return session, nil
+	default:
+		return session.state.handleServerMessage(session, msg)
+	}
+}
+
+// resync - Rebuilds the screen from the room state sent on resuming, whatever was shown before the
+// connection dropped.
+func (session Session) resync(msg messages.ServerMessage) Session {
+	session.playerNumber = msg.PlayerNumber
+	session.playerTurn = msg.PlayerTurn
+	session.game = msg.Game.GetGame()
+	if session.game != nil {
+		session.gameType = session.game.GetGameType()
+	}
+
+	switch msg.Phase {
+	case messages.RoomPhaseWaitingForOpponent:
+		session.state = NewSessionStateWaitingRoom(session.roomCode)
+	case messages.RoomPhaseGameSelection:
+		session.state = NewSessionStateInGameSelection(session.playerNumber)
+	case messages.RoomPhaseInGame:
+		session.state = NewSessionStateInGame(session.playerNumber, session.playerTurn, session.game, session.hotSeat)
+	case messages.RoomPhasePostGame:
+		session.gameResult = msg.GameResult
+		session.state = NewSessionStateEndGame(session.game, session.gameResult, session.playerNumber, session.hotSeat)
+	}
+	return session
+}
+
 func (session Session) handleRoomClosure(msg messages.ServerMessage) Session {
 	//TODO should move thsi outside of thsi function
 	// if session.state.GetType() == SessionStateTypeInGame {
@@ -162,6 +395,9 @@ func (session Session) handleRoomClosure(msg messages.ServerMessage) Session {
 	// }
 
 	session.errMsg = "A player has quit, closing the room."
+	if msg.ErrorMessage != "" {
+		session.errMsg = "The room has closed: " + msg.ErrorMessage + "."
+	}
 	session = session.setState(SessionStateTypeInMenu)
 	return session
 }
@@ -186,41 +422,129 @@ func (session Session) handleGameOver(gameResult messages.GameResult, quittingPl
 }
 
 func (session Session) WriteToServer(msg messages.ClientMessage) error {
-	return session.wsDriver.WriteToServer(msg)
+	if session.driver == nil {
+		return errors.New("not connected to a room")
+	}
+	switch msg.Type {
+	case messages.ClientJoinRoom, messages.ClientCreateRoom, messages.ClientQuickMatch:
+		//every way into a room introduces the player by name, and by account if logged in
+		msg.Nickname = session.config.Nickname
+		msg.SessionToken = session.config.SessionToken
+	}
+	return session.driver.WriteToServer(msg)
 }
 
 func (session Session) setState(state SessionStateType) Session {
 	switch state {
 	case SessionStateTypeInMenu:
-		session.wsDriver.CloseWS()
-		session.wsDriver = nil
-		session.driverToSession = make(chan messages.ServerMessage)
-		session.state = NewSessionStateInMenu()
+		//a closed driver closes its channel, so the next driver needs fresh ones. Without a driver
+		//the listener is still waiting on the current channels and they are kept
+		if session.driver != nil {
+			session.driver.Close()
+			session.driver = nil
+			session.driverToSession = make(chan messages.ServerMessage)
+			session.driverStatus = make(chan bool)
+			session.driverLatency = make(chan time.Duration)
+		}
+		session.latency = 0
+		session.hotSeat = false
+		session.playerNames = [2]string{}
+		session.clocks = gameClocks{}
+		session.chat = newChatPane()
+		session.emote.text = ""
+		session.reconnecting = false
+		session.opponentAway = false
+		session.goingAway = false
+		session.state = NewSessionStateInMenu(session.config)
 	case SessionStateTypeWaitingRoom:
-		acceptableStates := []SessionStateType{SessionStateTypeInMenu, SessionStateTypeEndGame}
+		acceptableStates := []SessionStateType{SessionStateTypeInMenu, SessionStateTypeEndGame, SessionStateTypeLobby}
 		if !slices.Contains(acceptableStates, session.state.GetType()) {
 			panic(fmt.Sprintf("Unexpected state when transitioning to waiting room: %v", session.state.GetType()))
 		}
 		session.state = NewSessionStateWaitingRoom(session.roomCode)
 	case SessionStateTypeGameSelection:
-		if session.state.GetType() != SessionStateTypeWaitingRoom {
+		acceptableStates := []SessionStateType{SessionStateTypeWaitingRoom, SessionStateTypeEndGame}
+		if !slices.Contains(acceptableStates, session.state.GetType()) {
 			panic(fmt.Sprintf("Unexpected state when transitioning to game selection: %v", session.state.GetType()))
 		}
 		session.state = NewSessionStateInGameSelection(session.playerNumber)
 	case SessionStateTypeInGame:
-		if session.state.GetType() != SessionStateTypeGameSelection {
+		acceptableStates := []SessionStateType{SessionStateTypeGameSelection, SessionStateTypeEndGame, SessionStateTypeSearching, SessionStateTypeReplay}
+		if !slices.Contains(acceptableStates, session.state.GetType()) {
 			panic(fmt.Sprintf("Unexpected state when transitioning to in game: %v", session.state.GetType()))
 		}
-		session.state = NewSessionStateInGame(session.playerNumber, session.playerTurn, session.game)
+		session.state = NewSessionStateInGame(session.playerNumber, session.playerTurn, session.game, session.hotSeat)
 	case SessionStateTypeEndGame:
 		if session.state.GetType() != SessionStateTypeInGame {
 			panic(fmt.Sprintf("Unexpected state when transitioning to end game: %v", session.state.GetType()))
 		}
-		session.state = NewSessionStateEndGame(session.game, session.gameResult, session.playerNumber)
+		session.state = NewSessionStateEndGame(session.game, session.gameResult, session.playerNumber, session.hotSeat)
+	case SessionStateTypeSpectating:
+		acceptableStates := []SessionStateType{SessionStateTypeInMenu, SessionStateTypeLobby}
+		if !slices.Contains(acceptableStates, session.state.GetType()) {
+			panic(fmt.Sprintf("Unexpected state when transitioning to spectating: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateSpectating(session.roomCode, session.game, session.playerTurn)
+	case SessionStateTypeLobby:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to lobby: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateLobby()
+	case SessionStateTypeSearching:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to searching: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateSearching(session.gameType)
+	case SessionStateTypeAccount:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to account: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateAccount(session.config.Username)
+	case SessionStateTypeLeaderboard:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to leaderboard: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateLeaderboard(session.gameType, session.config.Username)
+	case SessionStateTypeReplayList:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to replay list: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateReplayList(session.config.loggedInAs())
+	case SessionStateTypeSettings:
+		if session.state.GetType() != SessionStateTypeInMenu {
+			panic(fmt.Sprintf("Unexpected state when transitioning to settings: %v", session.state.GetType()))
+		}
+		session.state = NewSessionStateSettings(session.config)
+	case SessionStateTypeReplay:
+		if session.state.GetType() != SessionStateTypeReplayList {
+			panic(fmt.Sprintf("Unexpected state when transitioning to replay: %v", session.state.GetType()))
+		}
+		//a replay needs no connection, so it is let go the same way as on returning to the menu
+		session = session.setState(SessionStateTypeInMenu)
+		session.state = NewSessionStateReplay(session.replay, session.config.loggedInAs())
 	}
 	return session
 }
 
+// playerNames - Who sits in each seat, for the screens that show the players. Names arrive with
+// the room's messages, and an empty one falls back to the seat number.
+type playerNames [2]string
+
+func (names *playerNames) setPlayerNames(nicknames [2]string) {
+	*names = nicknames
+}
+
+func (names playerNames) name(playerNumber int) string {
+	if playerNumber < 1 || playerNumber > 2 || names[playerNumber-1] == "" {
+		return fmt.Sprintf("Player %d", playerNumber)
+	}
+	return names[playerNumber-1]
+}
+
+func (names playerNames) matchup() string {
+	return names.name(1) + " vs " + names.name(2)
+}
+
 type SessionStateType int
 
 const (
@@ -229,6 +553,14 @@ const (
 	SessionStateTypeGameSelection
 	SessionStateTypeInGame
 	SessionStateTypeEndGame
+	SessionStateTypeSpectating
+	SessionStateTypeLobby
+	SessionStateTypeSearching
+	SessionStateTypeAccount
+	SessionStateTypeLeaderboard
+	SessionStateTypeReplayList
+	SessionStateTypeReplay
+	SessionStateTypeSettings
 )
 
 func (sType SessionStateType) String() string {
@@ -243,6 +575,22 @@ func (sType SessionStateType) String() string {
 		return "In Game"
 	case SessionStateTypeEndGame:
 		return "End Game"
+	case SessionStateTypeSpectating:
+		return "Spectating"
+	case SessionStateTypeLobby:
+		return "Lobby"
+	case SessionStateTypeSearching:
+		return "Searching"
+	case SessionStateTypeAccount:
+		return "Account"
+	case SessionStateTypeLeaderboard:
+		return "Leaderboard"
+	case SessionStateTypeReplayList:
+		return "Replay List"
+	case SessionStateTypeReplay:
+		return "Replay"
+	case SessionStateTypeSettings:
+		return "Settings"
 	default:
 		return "Unknown"
 	}
@@ -255,29 +603,192 @@ type SessionState interface {
 	handleServerMessage(session Session, msg messages.ServerMessage) (Session, error)
 }
 
+type MenuOption int
+
+const (
+	MenuOptionJoinRoom MenuOption = iota
+	MenuOptionPlayComputer
+	MenuOptionHotSeat
+	MenuOptionBrowseLobby
+	MenuOptionQuickMatch
+	MenuOptionCreateRoom
+	MenuOptionNickname
+	MenuOptionAccount
+	MenuOptionLeaderboard
+	MenuOptionReplays
+	MenuOptionSettings
+)
+
+func GetMenuOptions() []MenuOption {
+	return []MenuOption{
+		MenuOptionCreateRoom, MenuOptionJoinRoom, MenuOptionQuickMatch, MenuOptionBrowseLobby,
+		MenuOptionLeaderboard, MenuOptionReplays, MenuOptionPlayComputer, MenuOptionHotSeat,
+		MenuOptionNickname, MenuOptionAccount, MenuOptionSettings,
+	}
+}
+
 type SessionStateInMenu struct {
-	textArea textarea.Model
+	textArea   textarea.Model
+	cursor     int
+	difficulty ai.Difficulty
+	//public lists a newly created room in the lobby, and rated has its games move the players' ratings
+	public bool
+	rated  bool
+	//timeControl indexes timeControlPresets for a newly created room
+	timeControl int
+	//quickMatchGame is the game to find a stranger for, leaderboardGame the one to see the best players of
+	quickMatchGame  game.GameType
+	leaderboardGame game.GameType
+	//while promptingPassword, keys go to the password box and pendingJoin is sent once it is filled in
+	passwordInput     textinput.Model
+	promptingPassword bool
+	pendingJoin       messages.ClientMessage
+	nicknameInput     textinput.Model
+	//username is who we are logged in as, if anyone
+	username string
 }
 
 func (SessionState SessionStateInMenu) GetType() SessionStateType {
 	return SessionStateTypeInMenu
 }
 
-func NewSessionStateInMenu() *SessionStateInMenu {
+func NewSessionStateInMenu(config Config) *SessionStateInMenu {
 	textArea := textarea.New()
 	textArea.Placeholder = "Enter room code."
 	textArea.Focus()
 	textArea.Prompt = " "
-	textArea.CharLimit = 5
+	textArea.CharLimit = gameroom.CodeLength
 	textArea.SetWidth(30)
 	textArea.SetHeight(1)
 	textArea.FocusedStyle.CursorLine = lipgloss.NewStyle()
 	textArea.ShowLineNumbers = false
 	textArea.KeyMap.InsertNewline.SetEnabled(false)
-	return &SessionStateInMenu{textArea: textArea}
+
+	passwordInput := textinput.New()
+	passwordInput.EchoMode = textinput.EchoPassword
+	passwordInput.CharLimit = 64
+	passwordInput.Width = 30
+
+	nicknameInput := textinput.New()
+	nicknameInput.Placeholder = "Pick a name others will see"
+	nicknameInput.CharLimit = gameroom.MaxNicknameLength
+	nicknameInput.Width = 30
+	nicknameInput.SetValue(config.Nickname)
+	return &SessionStateInMenu{
+		textArea:      textArea,
+		passwordInput: passwordInput,
+		nicknameInput: nicknameInput,
+		difficulty:    ai.DifficultyMedium,
+		username:      config.loggedInAs(),
+	}
 }
 
 func (state *SessionStateInMenu) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if state.promptingPassword {
+		return state.handlePasswordInput(msg, session)
+	}
+
+	switch msg.String() {
+	case "up", "shift+tab":
+		if state.cursor > 0 {
+			state.cursor--
+		}
+		return session, state.focusCursor()
+	case "down", "tab":
+		if state.cursor < len(GetMenuOptions())-1 {
+			state.cursor++
+		}
+		return session, state.focusCursor()
+	}
+
+	switch GetMenuOptions()[state.cursor] {
+	case MenuOptionPlayComputer:
+		return state.handlePlayComputerInput(msg, session)
+	case MenuOptionHotSeat:
+		return state.handleHotSeatInput(msg, session)
+	case MenuOptionBrowseLobby:
+		return state.handleBrowseLobbyInput(msg, session)
+	case MenuOptionLeaderboard:
+		return state.handleLeaderboardInput(msg, session)
+	case MenuOptionReplays:
+		return state.handleReplaysInput(msg, session)
+	case MenuOptionQuickMatch:
+		return state.handleQuickMatchInput(msg, session)
+	case MenuOptionCreateRoom:
+		return state.handleCreateRoomInput(msg, session)
+	case MenuOptionNickname:
+		return state.handleNicknameInput(msg, session)
+	case MenuOptionAccount:
+		return state.handleAccountInput(msg, session)
+	case MenuOptionSettings:
+		return state.handleSettingsInput(msg, session)
+	default:
+		return state.handleJoinRoomInput(msg, session)
+	}
+}
+
+// focusCursor - Only the room code and nickname boxes take typed input, so each is focused only
+// while selected.
+func (state *SessionStateInMenu) focusCursor() tea.Cmd {
+	state.textArea.Blur()
+	state.nicknameInput.Blur()
+	switch GetMenuOptions()[state.cursor] {
+	case MenuOptionJoinRoom:
+		return state.textArea.Focus()
+	case MenuOptionNickname:
+		return state.nicknameInput.Focus()
+	}
+	return nil
+}
+
+func (state *SessionStateInMenu) handleAccountInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", " ":
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		session = session.setState(SessionStateTypeAccount)
+		if session.config.SessionToken == "" {
+			return session, nil
+		}
+		//already logged in, so fetch the latest stats
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:         messages.ClientLogin,
+			SessionToken: session.config.SessionToken,
+		})
+	}
+	return session, nil
+}
+
+func (state *SessionStateInMenu) handleSettingsInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", " ":
+		return session.setState(SessionStateTypeSettings), nil
+	}
+	return session, nil
+}
+
+func (state *SessionStateInMenu) handleNicknameInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		state.nicknameInput, cmd = state.nicknameInput.Update(msg)
+		return session, cmd
+	}
+
+	nickname, err := gameroom.NormalizeNickname(state.nicknameInput.Value())
+	if err != nil {
+		return session, func() tea.Msg { return ErrMsg{err} }
+	}
+	state.nicknameInput.SetValue(nickname)
+	session.config.Nickname = nickname
+	if err := session.config.Save(); err != nil {
+		return session, func() tea.Msg { return ErrMsg{err} }
+	}
+	return session, nil
+}
+
+func (state *SessionStateInMenu) handleJoinRoomInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
 	var (
 		tiCmd     tea.Cmd
 		serverCmd tea.Cmd
@@ -289,11 +800,16 @@ func (state *SessionStateInMenu) HandleUserInput(msg tea.KeyMsg, session Session
 		if state.textArea.Value() == "" {
 			return session, func() tea.Msg { return ErrMsg{errors.New("Please enter a code.")} }
 		}
+		//codes are shown in capitals, but nobody should have to type them that way
+		code := strings.ToUpper(state.textArea.Value())
+		if err := gameroom.ValidateCode(code); err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
 		session, err := session.StartWS(session.serverUrl)
 		if err != nil {
 			return session, func() tea.Msg { return ErrMsg{err} }
 		}
-		session.roomCode = state.textArea.Value()
+		session.roomCode = code
 		joinMsg := messages.ClientMessage{
 			Type:     messages.ClientJoinRoom,
 			RoomCode: session.roomCode,
@@ -305,78 +821,357 @@ func (state *SessionStateInMenu) HandleUserInput(msg tea.KeyMsg, session Session
 	}
 }
 
-func (state SessionStateInMenu) GetDisplayString() string {
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#7D56F4")).
-		Padding(0, 1).
-		MarginBottom(1)
-
-	instructionStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#626262")).
-		MarginBottom(2)
-
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#874BFD")).
-		Padding(1).
-		MarginTop(1)
-
-	title := titleStyle.Render("🎮 ASCII ARCADE")
-	instruction := instructionStyle.Render("Enter a room code to join or create a game room")
-	textBox := boxStyle.Render(state.textArea.View())
+func (state *SessionStateInMenu) handlePlayComputerInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	difficulties := ai.GetDifficulties()
+	switch msg.String() {
+	case "left", "h", "a":
+		if state.difficulty > difficulties[0] {
+			state.difficulty--
+		}
+	case "right", "l", "d":
+		if state.difficulty < difficulties[len(difficulties)-1] {
+			state.difficulty++
+		}
+	case "enter", " ":
+		session = session.StartLocalGame(state.difficulty)
+		session.roomCode = localRoomCode
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientJoinRoom,
+		})
+	}
+	return session, nil
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, title, instruction, textBox)
+func (state *SessionStateInMenu) handleHotSeatInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", " ":
+		session = session.StartHotSeatGame()
+		session.roomCode = localRoomCode
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientJoinRoom,
+		})
+	}
+	return session, nil
 }
 
-func (state *SessionStateInMenu) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
-	switch msg.Type {
-	case messages.ServerRoomJoined:
-		session.playerNumber = msg.PlayerNumber
-		session = session.setState(SessionStateTypeWaitingRoom)
-	default:
-		return session, fmt.Errorf("unexpected server message type whle in menu: %v", msg.Type)
+func (state *SessionStateInMenu) handleCreateRoomInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+p", "p":
+		state.public = !state.public
+	case "r":
+		//a rated room moves the logged in players' ratings, not just their win and loss counts
+		state.rated = !state.rated
+	case "t":
+		state.timeControl = (state.timeControl + 1) % len(timeControlPresets)
+	case "enter", " ":
+		//the password is optional, an empty one leaves the room open to anyone with the code
+		return session, state.promptPassword(messages.ClientMessage{
+			Type:        messages.ClientCreateRoom,
+			Public:      state.public,
+			Rated:       state.rated,
+			TimeControl: timeControlPresets[state.timeControl],
+		}, "Optional, Enter to skip")
 	}
 	return session, nil
 }
 
-type SessionStateWaitingRoom struct {
-	roomCode string
+func (state *SessionStateInMenu) promptPassword(join messages.ClientMessage, placeholder string) tea.Cmd {
+	state.pendingJoin = join
+	state.promptingPassword = true
+	state.passwordInput.Reset()
+	state.passwordInput.Placeholder = placeholder
+	state.textArea.Blur()
+	return state.passwordInput.Focus()
 }
 
-func (SessionState SessionStateWaitingRoom) GetType() SessionStateType {
-	return SessionStateTypeWaitingRoom
+func (state *SessionStateInMenu) handlePasswordInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		state.promptingPassword = false
+		state.passwordInput.Blur()
+		return session, state.focusCursor()
+	case "enter":
+		join := state.pendingJoin
+		join.Password = state.passwordInput.Value()
+		state.promptingPassword = false
+		state.passwordInput.Blur()
+
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		return session, session.SendMsgToServer(join)
+	}
+
+	var cmd tea.Cmd
+	state.passwordInput, cmd = state.passwordInput.Update(msg)
+	return session, cmd
 }
 
-func NewSessionStateWaitingRoom(roomCode string) *SessionStateWaitingRoom {
-	return &SessionStateWaitingRoom{
-		roomCode: roomCode,
+func (state *SessionStateInMenu) handleQuickMatchInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	gameTypes := game.GetGameTypes()
+	switch msg.String() {
+	case "left", "h", "a":
+		if state.quickMatchGame > gameTypes[0] {
+			state.quickMatchGame--
+		}
+	case "right", "l", "d":
+		if state.quickMatchGame < gameTypes[len(gameTypes)-1] {
+			state.quickMatchGame++
+		}
+	case "enter", " ":
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		session.gameType = state.quickMatchGame
+		session = session.setState(SessionStateTypeSearching)
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:     messages.ClientQuickMatch,
+			GameType: state.quickMatchGame,
+		})
 	}
+	return session, nil
 }
 
-func (state *SessionStateWaitingRoom) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+func (state *SessionStateInMenu) handleLeaderboardInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	gameTypes := game.GetGameTypes()
 	switch msg.String() {
-	case "q":
+	case "left", "h", "a":
+		if state.leaderboardGame > gameTypes[0] {
+			state.leaderboardGame--
+		}
+	case "right", "l", "d":
+		if state.leaderboardGame < gameTypes[len(gameTypes)-1] {
+			state.leaderboardGame++
+		}
+	case "enter", " ":
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		session.gameType = state.leaderboardGame
+		session = session.setState(SessionStateTypeLeaderboard)
 		return session, session.SendMsgToServer(messages.ClientMessage{
-			Type: messages.ClientQuitRoom,
+			Type:     messages.ClientLeaderboard,
+			GameType: state.leaderboardGame,
 		})
-	default:
-		return session, nil
 	}
+	return session, nil
 }
 
-func (state SessionStateWaitingRoom) GetDisplayString() string {
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FAFAFA")).
-		Background(lipgloss.Color("#FF6B35")).
-		Padding(0, 1).
-		MarginBottom(1)
+func (state *SessionStateInMenu) handleReplaysInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", " ":
+		//game files open without a server, so only a logged in player needs one, to list their games
+		if session.config.loggedInAs() == "" {
+			return session.setState(SessionStateTypeReplayList), nil
+		}
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		session = session.setState(SessionStateTypeReplayList)
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:         messages.ClientGameHistory,
+			SessionToken: session.config.SessionToken,
+			Limit:        replayHistorySize,
+		})
+	}
+	return session, nil
+}
 
-	statusStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#626262")).
-		Italic(true).
+func (state *SessionStateInMenu) handleBrowseLobbyInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", " ":
+		session, err := session.StartWS(session.serverUrl)
+		if err != nil {
+			return session, func() tea.Msg { return ErrMsg{err} }
+		}
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientListRooms,
+		})
+	}
+	return session, nil
+}
+
+func (state SessionStateInMenu) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		MarginBottom(2)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#874BFD")).
+		Padding(1).
+		MarginTop(1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1)
+
+	unselectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("🎮 ASCII ARCADE")
+	instruction := instructionStyle.Render("Create a room and share its code, join a friend's room, or practice against the computer")
+
+	var options []string
+	for i, option := range GetMenuOptions() {
+		optionStyle := unselectedStyle
+		prefix := "  "
+		if i == state.cursor {
+			optionStyle = selectedStyle
+			prefix = "▶ "
+		}
+
+		switch option {
+		case MenuOptionCreateRoom:
+			publicBox := "[ ]"
+			if state.public {
+				publicBox = "[x]"
+			}
+			ratedBox := "[ ]"
+			if state.rated {
+				ratedBox = "[x]"
+			}
+			clock := "⏱ " + timeControlPresets[state.timeControl].String()
+			options = append(options, optionStyle.Render(prefix+"Create Room "+publicBox+" Public "+ratedBox+" Rated "+clock))
+		case MenuOptionJoinRoom:
+			options = append(options, optionStyle.Render(prefix+"Join Room"))
+			options = append(options, boxStyle.Render(state.textArea.View()))
+		case MenuOptionQuickMatch:
+			options = append(options, optionStyle.Render(prefix+"Quick Match: ◀ "+state.quickMatchGame.String()+" ▶"))
+		case MenuOptionBrowseLobby:
+			options = append(options, optionStyle.Render(prefix+"Browse Public Rooms"))
+		case MenuOptionLeaderboard:
+			options = append(options, optionStyle.Render(prefix+"Leaderboard: ◀ "+state.leaderboardGame.String()+" ▶"))
+		case MenuOptionReplays:
+			options = append(options, optionStyle.Render(prefix+"Replays"))
+		case MenuOptionPlayComputer:
+			options = append(options, optionStyle.Render(prefix+"Play vs Computer: ◀ "+state.difficulty.String()+" ▶"))
+		case MenuOptionHotSeat:
+			options = append(options, optionStyle.Render(prefix+"Hot Seat: two players, one keyboard"))
+		case MenuOptionNickname:
+			options = append(options, optionStyle.Render(prefix+"Nickname"))
+			options = append(options, boxStyle.Render(state.nicknameInput.View()))
+		case MenuOptionAccount:
+			account := "Account: log in or register"
+			if state.username != "" {
+				account = "Account: " + state.username
+			}
+			options = append(options, optionStyle.Render(prefix+account))
+		case MenuOptionSettings:
+			options = append(options, optionStyle.Render(prefix+"Settings: bell and notifications"))
+		}
+
+		if state.promptingPassword && option == state.pendingJoinOption() {
+			options = append(options, boxStyle.Render("🔒 Room password\n"+state.passwordInput.View()+"\n\nEnter Confirm • Esc Cancel"))
+		}
+	}
+
+	menu := lipgloss.JoinVertical(lipgloss.Left, options...)
+	controls := controlsStyle.Render("↑/↓ Navigate • ←/→ Game/Difficulty • p Public Room • r Rated Room • t Clock • Enter Select • ctrl+c Quit")
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, instruction, menu, controls)
+}
+
+// pendingJoinOption - The menu option the password prompt is shown under.
+func (state SessionStateInMenu) pendingJoinOption() MenuOption {
+	if state.pendingJoin.Type == messages.ClientCreateRoom {
+		return MenuOptionCreateRoom
+	}
+	return MenuOptionJoinRoom
+}
+
+func (state *SessionStateInMenu) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerRoomJoined:
+		session.playerNumber = msg.PlayerNumber
+		//a created room's code comes from the server
+		session.roomCode = msg.RoomCode
+		session = session.setState(SessionStateTypeWaitingRoom)
+	case messages.ServerRoomUnavailable:
+		//drop the connection but keep the menu as it was, so a mistyped code can be fixed
+		session = session.setState(SessionStateTypeInMenu)
+		session.state = state
+		return session, errors.New(msg.ErrorMessage)
+	case messages.ServerPasswordRequired:
+		session = session.setState(SessionStateTypeInMenu)
+		session.state = state
+		state.promptPassword(messages.ClientMessage{
+			Type:     messages.ClientJoinRoom,
+			RoomCode: session.roomCode,
+		}, "Enter the room's password")
+		return session, errors.New(msg.ErrorMessage)
+	case messages.ServerSpectating:
+		//the room already had two players, so we watch instead
+		session.game = msg.Game.GetGame()
+		session.playerTurn = msg.PlayerTurn
+		session = session.setState(SessionStateTypeSpectating)
+	case messages.ServerRoomList:
+		session = session.setState(SessionStateTypeLobby)
+		return session.state.handleServerMessage(session, msg)
+	default:
+		return session, fmt.Errorf("unexpected server message type whle in menu: %v", msg.Type)
+	}
+	return session, nil
+}
+
+type SessionStateWaitingRoom struct {
+	playerNames
+	roomCode string
+}
+
+func (SessionState SessionStateWaitingRoom) GetType() SessionStateType {
+	return SessionStateTypeWaitingRoom
+}
+
+func NewSessionStateWaitingRoom(roomCode string) *SessionStateWaitingRoom {
+	return &SessionStateWaitingRoom{
+		roomCode: roomCode,
+	}
+}
+
+func (state *SessionStateWaitingRoom) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientQuitRoom,
+		})
+	default:
+		return session, nil
+	}
+}
+
+func (state SessionStateWaitingRoom) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#FF6B35")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
 		MarginBottom(1)
 
 	instructionStyle := lipgloss.NewStyle().
@@ -387,7 +1182,7 @@ func (state SessionStateWaitingRoom) GetDisplayString() string {
 		MarginTop(1)
 
 	title := titleStyle.Render("WAITING ROOM | ROOM CODE: " + state.roomCode)
-	status := statusStyle.Render("Waiting for another player to join...")
+	status := statusStyle.Render(state.name(1) + " is waiting for another player to join...")
 	instruction := instructionStyle.Render("Press 'q' to quit and return to main menu")
 
 	return lipgloss.JoinVertical(lipgloss.Left, title, status, instruction)
@@ -407,6 +1202,7 @@ func (state *SessionStateWaitingRoom) handleServerMessage(session Session, msg m
 }
 
 type SessionStateInGameSelection struct {
+	playerNames
 	cursor    int
 	playerNum int
 }
@@ -476,7 +1272,7 @@ func (state SessionStateInGameSelection) GetDisplayString() string {
 			MarginTop(1)
 
 		title := titleStyle.Render("🎯 GAME SELECTION")
-		waiting := waitingStyle.Render("Waiting for Player 1 to select a game...")
+		waiting := waitingStyle.Render(state.matchup() + "\n\nWaiting for " + state.name(1) + " to select a game...")
 
 		return lipgloss.JoinVertical(lipgloss.Left, title, waiting)
 	}
@@ -503,7 +1299,7 @@ func (state SessionStateInGameSelection) GetDisplayString() string {
 		MarginTop(1)
 
 	title := titleStyle.Render("🎯 GAME SELECTION")
-	instruction := instructionStyle.Render("Choose a game to play:")
+	instruction := instructionStyle.Render(state.matchup() + "\n\nChoose a game to play:")
 
 	var gameOptions []string
 	for i, gameType := range game.GetGameTypes() {
@@ -523,6 +1319,7 @@ func (state SessionStateInGameSelection) GetDisplayString() string {
 func (state *SessionStateInGameSelection) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
 	switch msg.Type {
 	case messages.ServerGameStarted:
+		session.playerNumber = msg.PlayerNumber
 		session.game = msg.Game.GetGame()
 		session.gameType = session.game.GetGameType()
 		session.playerTurn = msg.PlayerTurn
@@ -536,29 +1333,61 @@ func (state *SessionStateInGameSelection) handleServerMessage(session Session, m
 }
 
 type SessionStateInGame struct {
+	playerNames
+	gameClocks
 	playerNum        int
 	isPlayerTurn     bool
 	cursor           vector.Vector
 	game             game.Game
 	selectedSquare   vector.Vector
 	inMoveSelectMode bool
+	//in hot seat mode playerNum follows whoever's turn it is
+	hotSeat         bool
+	awaitingHandoff bool
+	//incoming is the opponent's offer waiting on y/n, sent what this player is waiting to hear back
+	//about, and notice how the last offer went
+	incoming    *messages.Offer
+	sent        [2]bool
+	offerNotice string
+	latency     time.Duration
 }
 
 func (SessionState SessionStateInGame) GetType() SessionStateType {
 	return SessionStateTypeInGame
 }
 
-func NewSessionStateInGame(playerNum int, playerTurn int, game game.Game) *SessionStateInGame {
+func NewSessionStateInGame(playerNum int, playerTurn int, game game.Game, hotSeat bool) *SessionStateInGame {
 
 	return &SessionStateInGame{
 		playerNum:      playerNum,
 		game:           game,
 		isPlayerTurn:   playerNum == playerTurn,
 		selectedSquare: vector.NewVector(-1, -1),
+		hotSeat:        hotSeat,
 	}
 }
 
+// passTurn - Hands a hot seat game to the next player. Checkers boards are drawn from the mover's
+// side, so the players swap seats behind a handoff screen before the board flips.
+func (state *SessionStateInGame) passTurn(playerTurn int) {
+	state.playerNum = playerTurn
+	state.inMoveSelectMode = false
+	state.awaitingHandoff = state.game.GetGameType() == game.GameTypeCheckers
+}
+
 func (state *SessionStateInGame) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if state.awaitingHandoff {
+		state.awaitingHandoff = false
+		return session, nil
+	}
+	state.offerNotice = ""
+	//offers are between two people at different keyboards, so hot seat games go without
+	if !state.hotSeat {
+		if handled, cmd := state.handleOfferInput(msg, session); handled {
+			return session, cmd
+		}
+	}
+
 	switch state.game.GetGameType() {
 	case game.GameTypeTicTacToe:
 		return state.handleTicTacToeInput(msg, session)
@@ -569,6 +1398,28 @@ func (state *SessionStateInGame) HandleUserInput(msg tea.KeyMsg, session Session
 	}
 }
 
+// handleOfferInput - o offers a draw and u asks to take back a move, while y and n answer the
+// opponent's offer. Reports whether the key was one of these.
+func (state *SessionStateInGame) handleOfferInput(msg tea.KeyMsg, session Session) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "o", "u":
+		offer := messages.OfferDraw
+		if msg.String() == "u" {
+			offer = messages.OfferTakeback
+		}
+		state.sent[offer] = true
+		return true, session.SendMsgToServer(messages.ClientMessage{Type: messages.ClientMakeOffer, Offer: offer})
+	case "y", "n":
+		if state.incoming == nil {
+			return false, nil
+		}
+		answer := messages.ClientMessage{Type: messages.ClientAnswerOffer, Offer: *state.incoming, Accept: msg.String() == "y"}
+		state.incoming = nil
+		return true, session.SendMsgToServer(answer)
+	}
+	return false, nil
+}
+
 func (state *SessionStateInGame) handleTicTacToeInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "c", "q":
@@ -679,6 +1530,10 @@ func (state *SessionStateInGame) handleCheckersInput(msg tea.KeyMsg, session Ses
 }
 
 func (state SessionStateInGame) GetDisplayString() string {
+	if state.awaitingHandoff {
+		return state.getHandoffDisplayString()
+	}
+
 	infoStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#626262")).
 		Background(lipgloss.Color("#1C1C1E")).
@@ -698,28 +1553,113 @@ func (state SessionStateInGame) GetDisplayString() string {
 	} else {
 		playerTurnMsg = "Waiting for opponents move..."
 	}
-	info := infoStyle.Render(fmt.Sprintf("Player: %d | %v", state.playerNum, playerTurnMsg))
+	players := fmt.Sprintf("%v (you) vs %v", state.name(state.playerNum), state.name(3-state.playerNum))
+	if state.hotSeat {
+		players = state.matchup()
+	}
+	status := players + " | " + playerTurnMsg
+	if state.latency > 0 {
+		status += " | " + formatLatency(state.latency)
+	}
+	info := infoStyle.Render(status)
 	var controlStr string
 	if !state.inMoveSelectMode {
 		controlStr = "WASD/Arrow Keys Move • Enter/Space Select • q/c Concede"
 	} else {
 		controlStr = "e Move Left • r Move Right • d Move Back Left • f Move Back Right • Backspace Deselect Square • q/c Concede"
 	}
+	if !state.hotSeat {
+		controlStr += " • o Offer Draw • u Takeback"
+	}
+
+	offerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#FF6B35")).
+		Padding(0, 1).
+		MarginBottom(1)
 
 	controls := controlsStyle.Render(controlStr)
-	return lipgloss.JoinVertical(lipgloss.Left, board, info, controls)
+	sections := []string{board, info}
+	if clocks := state.gameClocks.render(state.playerNames); clocks != "" {
+		sections = append([]string{clocks}, sections...)
+	}
+	if offers := state.offerString(); offers != "" {
+		sections = append(sections, offerStyle.Render(offers))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append(sections, controls)...)
+}
+
+// offerString - The opponent's offer waiting on an answer, or else what has become of this player's own.
+func (state SessionStateInGame) offerString() string {
+	opponent := state.name(3 - state.playerNum)
+	switch {
+	case state.incoming != nil && *state.incoming == messages.OfferDraw:
+		return opponent + " offers a draw. Accept? y/n"
+	case state.incoming != nil:
+		return opponent + " asks to take back their last move. Allow it? y/n"
+	case state.offerNotice != "":
+		return state.offerNotice
+	case state.sent[messages.OfferDraw]:
+		return "Draw offered, waiting for " + opponent + "..."
+	case state.sent[messages.OfferTakeback]:
+		return "Takeback asked for, waiting for " + opponent + "..."
+	}
+	return ""
+}
+
+func (state SessionStateInGame) getHandoffDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#FF6B35")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("PASS THE KEYBOARD")
+	instruction := instructionStyle.Render(fmt.Sprintf("%v, it's your turn. Press any key when ready.", state.name(state.playerNum)))
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, instruction)
 }
 
 func (state *SessionStateInGame) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
 	switch msg.Type {
 	case messages.ServerError:
+		//a refused offer comes back as an error, and leaves nothing to wait for
+		state.sent = [2]bool{}
 		return session, errors.New(msg.ErrorMessage)
 	case messages.ServerTurnResult:
 		session.game = msg.Game.GetGame()
 		session.playerTurn = msg.PlayerTurn
 		state.game = session.game
+		if state.hotSeat {
+			state.passTurn(msg.PlayerTurn)
+			session.playerNumber = msg.PlayerTurn
+		}
 		state.isPlayerTurn = session.playerTurn == state.playerNum
 		session.playerTurn = msg.PlayerTurn
+		//any move withdraws the offers made before it
+		state.incoming = nil
+		state.sent = [2]bool{}
+	case messages.ServerOffer:
+		offer := msg.Offer
+		state.incoming = &offer
+	case messages.ServerOfferAnswered:
+		state.sent[msg.Offer] = false
+		if msg.PlayerNumber != state.playerNum {
+			answer := "declined"
+			if msg.Accepted {
+				answer = "accepted"
+			}
+			state.offerNotice = fmt.Sprintf("%v %v your %v offer.", state.name(msg.PlayerNumber), answer, strings.ToLower(msg.Offer.String()))
+		}
 	case messages.ServerGameFinished:
 		session.game = msg.Game.GetGame()
 		session.gameResult = msg.GameResult
@@ -735,16 +1675,23 @@ func (state *SessionStateInGame) handleServerMessage(session Session, msg messag
 }
 
 type SessionStateEndGame struct {
+	playerNames
+	gameClocks
 	game       game.Game
 	gameResult messages.GameResult
 	playerNum  int
+	hotSeat    bool
+	//latest rematch vote from each player, indexed by player number - 1
+	voted [2]bool
+	votes [2]messages.RematchOption
 }
 
-func NewSessionStateEndGame(game game.Game, gameResult messages.GameResult, playerNum int) *SessionStateEndGame {
+func NewSessionStateEndGame(game game.Game, gameResult messages.GameResult, playerNum int, hotSeat bool) *SessionStateEndGame {
 	return &SessionStateEndGame{
 		game:       game,
 		gameResult: gameResult,
 		playerNum:  playerNum,
+		hotSeat:    hotSeat,
 	}
 }
 
@@ -774,34 +1721,69 @@ func (state SessionStateEndGame) GetDisplayString() string {
 	board := state.game.DisplayBoard(vector.NewVector(-1, -1), state.playerNum)
 
 	var resultStr string
-	switch state.gameResult {
-	case messages.GameResultPlayerWin:
+	switch {
+	case state.hotSeat && state.game.GetGameStatus() == game.GameStatusPlayer1Win:
+		resultStyle = resultStyle.Background(lipgloss.Color("#32D74B"))
+		resultStr = state.name(1) + " Won!"
+	case state.hotSeat && state.game.GetGameStatus() == game.GameStatusPlayer2Win:
+		resultStyle = resultStyle.Background(lipgloss.Color("#32D74B"))
+		resultStr = state.name(2) + " Won!"
+	case state.gameResult == messages.GameResultPlayerWin:
 		resultStyle = resultStyle.Background(lipgloss.Color("#32D74B"))
 		resultStr = "You Won!"
-	case messages.GameResultPlayerLose:
+	case state.gameResult == messages.GameResultPlayerLose:
 		resultStyle = resultStyle.Background(lipgloss.Color("#FF3B30"))
 		resultStr = "You Lost"
-	case messages.GameResultDraw:
+	case state.gameResult == messages.GameResultDraw:
 		resultStyle = resultStyle.Background(lipgloss.Color("#FF9500"))
 		resultStr = "It's a Draw!"
 	default:
 		resultStr = "Game Over"
 	}
 
-	result := resultStyle.Render(resultStr)
-	prompt := promptStyle.Render("Play again? (y/n)")
-	controls := controlsStyle.Render("y Play Again • n/q Quit to Menu")
+	if state.timedOut != 0 {
+		resultStr += " • " + state.name(state.timedOut) + " ran out of time"
+	}
+	result := resultStyle.Render(resultStr + " • " + state.matchup())
+	prompt := promptStyle.Render(state.getVoteString())
+	controls := controlsStyle.Render("y Rematch • s Swap Sides • g New Game • n/q Quit to Menu")
+	if state.hotSeat {
+		controls = controlsStyle.Render("y Rematch • g New Game • n/q Quit to Menu")
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, board, result, prompt, controls)
 }
 
+// getVoteString - Describes where the rematch vote stands. In hot seat mode one key press votes
+// for both players, so only the opponent's vote is worth showing online.
+func (state SessionStateEndGame) getVoteString() string {
+	if state.hotSeat {
+		return "Play again?"
+	}
+
+	mine, theirs := state.playerNum-1, 2-state.playerNum
+	yours := "Play again?"
+	if state.voted[mine] {
+		yours = "You voted: " + state.votes[mine].String()
+	}
+	if !state.voted[theirs] {
+		return yours + " • Waiting for opponent..."
+	}
+	return yours + " • Opponent voted: " + state.votes[theirs].String()
+}
+
 func (state *SessionStateEndGame) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y":
-		return session, session.SendMsgToServer(messages.ClientMessage{
-			Type:     messages.ClientJoinRoom,
-			RoomCode: session.roomCode,
-		})
+		return session, state.sendVote(session, messages.RematchSameSides)
+	case "s":
+		if state.hotSeat {
+			//both players share the keyboard, so there are no sides to swap
+			return session, nil
+		}
+		return session, state.sendVote(session, messages.RematchSwapSides)
+	case "g":
+		return session, state.sendVote(session, messages.RematchNewGame)
 	case "n", "q":
 		return session, session.SendMsgToServer(messages.ClientMessage{
 			Type: messages.ClientQuitRoom,
@@ -811,11 +1793,33 @@ func (state *SessionStateEndGame) HandleUserInput(msg tea.KeyMsg, session Sessio
 	}
 }
 
+func (state *SessionStateEndGame) sendVote(session Session, option messages.RematchOption) tea.Cmd {
+	return session.SendMsgToServer(messages.ClientMessage{
+		Type:    messages.ClientRematchVote,
+		Rematch: option,
+	})
+}
+
 func (state *SessionStateEndGame) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
 	switch msg.Type {
-	case messages.ServerRoomJoined:
+	case messages.ServerRematchVote:
+		if msg.PlayerNumber != 1 && msg.PlayerNumber != 2 {
+			return session, fmt.Errorf("rematch vote from unknown player: %v", msg.PlayerNumber)
+		}
+		state.voted[msg.PlayerNumber-1] = true
+		state.votes[msg.PlayerNumber-1] = msg.Rematch
+	case messages.ServerGameStarted:
 		session.playerNumber = msg.PlayerNumber
-		session = session.setState(SessionStateTypeWaitingRoom)
+		session.game = msg.Game.GetGame()
+		session.gameType = session.game.GetGameType()
+		session.playerTurn = msg.PlayerTurn
+		session = session.setState(SessionStateTypeInGame)
+	case messages.ServerEnteredGameSelection:
+		if session.hotSeat {
+			//the last turn may have left player two at the keyboard, but player one picks the game
+			session.playerNumber = 1
+		}
+		session = session.setState(SessionStateTypeGameSelection)
 	case messages.ServerRoomClosed:
 		session = session.handleRoomClosure(msg)
 	default:
@@ -823,3 +1827,622 @@ func (state *SessionStateEndGame) handleServerMessage(session Session, msg messa
 	}
 	return session, nil
 }
+
+// SessionStateSpectating - Watching a room that already has two players. Spectators see every turn
+// but cannot act, and leaving does not affect the match.
+type SessionStateSpectating struct {
+	playerNames
+	gameClocks
+	roomCode   string
+	game       game.Game
+	playerTurn int
+	//the board is shown from player one's side unless flipped
+	flipped bool
+}
+
+func NewSessionStateSpectating(roomCode string, game game.Game, playerTurn int) *SessionStateSpectating {
+	return &SessionStateSpectating{
+		roomCode:   roomCode,
+		game:       game,
+		playerTurn: playerTurn,
+	}
+}
+
+func (state SessionStateSpectating) GetType() SessionStateType {
+	return SessionStateTypeSpectating
+}
+
+func (state SessionStateSpectating) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#5856D6")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	infoStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Background(lipgloss.Color("#1C1C1E")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1)
+
+	title := titleStyle.Render("👀 SPECTATING | ROOM CODE: " + state.roomCode)
+	controls := controlsStyle.Render("f Flip Board • q Leave")
+
+	if state.game == nil {
+		info := infoStyle.Render("The players are choosing a game...")
+		return lipgloss.JoinVertical(lipgloss.Left, title, info, controls)
+	}
+
+	viewingPlayer := 1
+	if state.flipped {
+		viewingPlayer = 2
+	}
+	board := state.game.DisplayBoard(vector.NewVector(-1, -1), viewingPlayer)
+
+	var infoStr string
+	switch state.game.GetGameStatus() {
+	case game.GameStatusPlayer1Win:
+		infoStr = state.name(1) + " Won!"
+	case game.GameStatusPlayer2Win:
+		infoStr = state.name(2) + " Won!"
+	case game.GameStatusDraw:
+		infoStr = "It's a Draw!"
+	default:
+		infoStr = state.name(state.playerTurn) + " to move"
+	}
+	info := infoStyle.Render(fmt.Sprintf("%v | Viewing as %v | %v", state.matchup(), state.name(viewingPlayer), infoStr))
+
+	if clocks := state.gameClocks.render(state.playerNames); clocks != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, title, clocks, board, info, controls)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, title, board, info, controls)
+}
+
+func (state *SessionStateSpectating) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "f":
+		state.flipped = !state.flipped
+		return session, nil
+	case "q":
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientQuitRoom,
+		})
+	default:
+		return session, nil
+	}
+}
+
+func (state *SessionStateSpectating) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerGameStarted, messages.ServerTurnResult:
+		state.game = msg.Game.GetGame()
+		state.playerTurn = msg.PlayerTurn
+	case messages.ServerGameFinished:
+		state.game = msg.Game.GetGame()
+	case messages.ServerEnteredGameSelection:
+		state.game = nil
+	case messages.ServerRoomClosed:
+		session = session.setState(SessionStateTypeInMenu)
+		//a quitting player ends the match, otherwise this is our own leave being confirmed
+		if msg.QuittingPlayerNum != 0 {
+			session.errMsg = fmt.Sprintf("%v left, the match is over.", state.name(msg.QuittingPlayerNum))
+		}
+		if msg.ErrorMessage != "" {
+			session.errMsg = "The room has closed: " + msg.ErrorMessage + "."
+		}
+	default:
+		return session, fmt.Errorf("unexpected server message type while spectating: %v", msg.Type)
+	}
+	return session, nil
+}
+
+// SessionStateLobby - Lists the public rooms on the server to pick one to join or watch.
+type SessionStateLobby struct {
+	rooms  []messages.RoomInfo
+	cursor int
+}
+
+func NewSessionStateLobby() *SessionStateLobby {
+	return &SessionStateLobby{}
+}
+
+func (state SessionStateLobby) GetType() SessionStateType {
+	return SessionStateTypeLobby
+}
+
+func (state SessionStateLobby) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1)
+
+	unselectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("🌐 PUBLIC ROOMS")
+	controls := controlsStyle.Render("↑/↓ Navigate • Enter Join • r Refresh • q Back to Menu • ★ Rated")
+
+	if len(state.rooms) == 0 {
+		status := statusStyle.Render("No public rooms right now. Create one from the menu!")
+		return lipgloss.JoinVertical(lipgloss.Left, title, status, controls)
+	}
+
+	rowFormat := "%-6s %-12s %-8s %-9s %-14s %s"
+	rows := []string{headerStyle.Render(fmt.Sprintf("  "+rowFormat, "CODE", "GAME", "PLAYERS", "WATCHING", "CLOCK", "STATUS"))}
+	for i, room := range state.rooms {
+		gameName := "-"
+		if room.Phase == messages.RoomPhaseInGame || room.Phase == messages.RoomPhasePostGame {
+			gameName = room.GameType.String()
+		}
+		status := room.Phase.String()
+		if room.Locked {
+			status += " 🔒"
+		}
+		if room.Rated {
+			status += " ★"
+		}
+		row := fmt.Sprintf(rowFormat, room.Code, gameName, fmt.Sprintf("%d/2", room.Players), fmt.Sprint(room.Spectators), room.TimeControl.String(), status)
+
+		if i == state.cursor {
+			rows = append(rows, selectedStyle.Render("▶ "+row))
+		} else {
+			rows = append(rows, unselectedStyle.Render("  "+row))
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, lipgloss.JoinVertical(lipgloss.Left, rows...), controls)
+}
+
+func (state *SessionStateLobby) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "w":
+		if state.cursor > 0 {
+			state.cursor--
+		}
+	case "down", "s":
+		if state.cursor < len(state.rooms)-1 {
+			state.cursor++
+		}
+	case "r":
+		//the list is a snapshot, so it only changes when asked for again
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientListRooms,
+		})
+	case "enter", " ":
+		if len(state.rooms) == 0 {
+			return session, nil
+		}
+		session.roomCode = state.rooms[state.cursor].Code
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:     messages.ClientJoinRoom,
+			RoomCode: session.roomCode,
+		})
+	case "q", "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	}
+	return session, nil
+}
+
+func (state *SessionStateLobby) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerRoomList:
+		state.rooms = msg.Rooms
+		state.cursor = max(0, min(state.cursor, len(state.rooms)-1))
+	case messages.ServerRoomJoined:
+		session.playerNumber = msg.PlayerNumber
+		session = session.setState(SessionStateTypeWaitingRoom)
+	case messages.ServerSpectating:
+		session.game = msg.Game.GetGame()
+		session.playerTurn = msg.PlayerTurn
+		session = session.setState(SessionStateTypeSpectating)
+	case messages.ServerRoomUnavailable:
+		return session, errors.New(msg.ErrorMessage)
+	case messages.ServerPasswordRequired:
+		return session, fmt.Errorf("room %v is locked, join it from the main menu with its password", session.roomCode)
+	default:
+		return session, fmt.Errorf("unexpected server message type while in lobby: %v", msg.Type)
+	}
+	return session, nil
+}
+
+// SessionStateSearching - Waits in the quick match queue until the server pairs us with someone.
+type SessionStateSearching struct {
+	gameType game.GameType
+	//matched is set once the server has seated us, the game starts a moment later
+	matched bool
+}
+
+func NewSessionStateSearching(gameType game.GameType) *SessionStateSearching {
+	return &SessionStateSearching{gameType: gameType}
+}
+
+func (state SessionStateSearching) GetType() SessionStateType {
+	return SessionStateTypeSearching
+}
+
+func (state SessionStateSearching) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#FF6B35")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		MarginBottom(1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("QUICK MATCH | " + state.gameType.String())
+	status := statusStyle.Render("Searching for a " + state.gameType.String() + " opponent...")
+	if state.matched {
+		status = statusStyle.Render("Opponent found, starting the game...")
+	}
+	instruction := instructionStyle.Render("Press 'q' to stop searching and return to main menu")
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, status, instruction)
+}
+
+func (state *SessionStateSearching) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		if state.matched {
+			return session, nil
+		}
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type: messages.ClientCancelQuickMatch,
+		})
+	}
+	return session, nil
+}
+
+func (state *SessionStateSearching) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerRoomJoined:
+		state.matched = true
+		session.playerNumber = msg.PlayerNumber
+		session.roomCode = msg.RoomCode
+	case messages.ServerGameStarted:
+		session.playerNumber = msg.PlayerNumber
+		session.game = msg.Game.GetGame()
+		session.gameType = session.game.GetGameType()
+		session.playerTurn = msg.PlayerTurn
+		session = session.setState(SessionStateTypeInGame)
+	case messages.ServerSearchCancelled:
+		session = session.setState(SessionStateTypeInMenu)
+	case messages.ServerRoomClosed:
+		session = session.setState(SessionStateTypeInMenu)
+		session.errMsg = "Your opponent left before the game started."
+	case messages.ServerError:
+		session = session.setState(SessionStateTypeInMenu)
+		return session, errors.New(msg.ErrorMessage)
+	default:
+		return session, fmt.Errorf("unexpected server message type while searching: %v", msg.Type)
+	}
+	return session, nil
+}
+
+// SessionStateAccount - Logs in to or registers an account on the server, and once logged in shows
+// the account's record at each game.
+type SessionStateAccount struct {
+	usernameInput textinput.Model
+	passwordInput textinput.Model
+	//account is only filled in once the server has confirmed who we are
+	account  messages.AccountInfo
+	loggedIn bool
+}
+
+func NewSessionStateAccount(username string) *SessionStateAccount {
+	usernameInput := textinput.New()
+	usernameInput.Placeholder = "Username"
+	usernameInput.CharLimit = 16
+	usernameInput.Width = 30
+	usernameInput.SetValue(username)
+	usernameInput.Focus()
+
+	passwordInput := textinput.New()
+	passwordInput.Placeholder = "Password"
+	passwordInput.EchoMode = textinput.EchoPassword
+	passwordInput.CharLimit = 64
+	passwordInput.Width = 30
+
+	return &SessionStateAccount{
+		usernameInput: usernameInput,
+		passwordInput: passwordInput,
+	}
+}
+
+func (state SessionStateAccount) GetType() SessionStateType {
+	return SessionStateTypeAccount
+}
+
+func (state SessionStateAccount) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(0, 1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#6B7280")).
+		Padding(1).
+		MarginTop(1)
+
+	if !state.loggedIn {
+		title := titleStyle.Render("ACCOUNT | Log In")
+		status := statusStyle.Render("Log in to keep your record across games, or register a new account")
+		form := lipgloss.JoinVertical(lipgloss.Left,
+			boxStyle.Render(state.usernameInput.View()),
+			boxStyle.Render(state.passwordInput.View()),
+		)
+		instruction := instructionStyle.Render("Tab Switch Field • Enter Log In • ctrl+r Register • Esc Back")
+		return lipgloss.JoinVertical(lipgloss.Left, title, status, form, instruction)
+	}
+
+	title := titleStyle.Render("ACCOUNT | " + state.account.Username)
+	rows := []string{fmt.Sprintf("%-14s %5s %7s %6s %7s", "Game", "Wins", "Losses", "Draws", "Rating")}
+	for _, gameType := range game.GetGameTypes() {
+		stats := state.account.Stats[gameType]
+		rows = append(rows, fmt.Sprintf("%-14s %5d %7d %6d %7s", gameType.String(), stats.Wins, stats.Losses, stats.Draws, ratingString(stats)))
+	}
+	record := boxStyle.Render(strings.Join(rows, "\n"))
+	instruction := instructionStyle.Render("Press 'l' to log out • 'q' to return to main menu")
+	return lipgloss.JoinVertical(lipgloss.Left, title, record, instruction)
+}
+
+func (state *SessionStateAccount) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	if state.loggedIn {
+		switch msg.String() {
+		case "l":
+			token := session.config.SessionToken
+			session.config.Username = ""
+			session.config.SessionToken = ""
+			if err := session.config.Save(); err != nil {
+				return session, func() tea.Msg { return ErrMsg{err} }
+			}
+			state.loggedIn = false
+			state.account = messages.AccountInfo{}
+			return session, session.SendMsgToServer(messages.ClientMessage{
+				Type:         messages.ClientLogout,
+				SessionToken: token,
+			})
+		case "q", "esc":
+			return session.setState(SessionStateTypeInMenu), nil
+		}
+		return session, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	case "tab", "shift+tab", "up", "down":
+		if state.usernameInput.Focused() {
+			state.usernameInput.Blur()
+			return session, state.passwordInput.Focus()
+		}
+		state.passwordInput.Blur()
+		return session, state.usernameInput.Focus()
+	case "enter", "ctrl+r":
+		msgType := messages.ClientLogin
+		if msg.String() == "ctrl+r" {
+			msgType = messages.ClientRegister
+		}
+		return session, session.SendMsgToServer(messages.ClientMessage{
+			Type:     msgType,
+			Username: strings.TrimSpace(state.usernameInput.Value()),
+			Password: state.passwordInput.Value(),
+		})
+	}
+
+	var cmd tea.Cmd
+	if state.usernameInput.Focused() {
+		state.usernameInput, cmd = state.usernameInput.Update(msg)
+	} else {
+		state.passwordInput, cmd = state.passwordInput.Update(msg)
+	}
+	return session, cmd
+}
+
+func (state *SessionStateAccount) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerLoggedIn:
+		state.loggedIn = true
+		state.account = msg.Account
+		state.passwordInput.SetValue("")
+		session.config.Username = msg.Account.Username
+		session.config.SessionToken = msg.SessionToken
+		if err := session.config.Save(); err != nil {
+			return session, err
+		}
+	case messages.ServerAuthFailed:
+		//a saved session the server no longer knows has expired, so forget it and ask for the password
+		if session.config.SessionToken != "" {
+			session.config.SessionToken = ""
+			if err := session.config.Save(); err != nil {
+				return session, err
+			}
+		}
+		state.passwordInput.SetValue("")
+		return session, errors.New(msg.ErrorMessage)
+	case messages.ServerError:
+		return session, errors.New(msg.ErrorMessage)
+	default:
+		return session, fmt.Errorf("unexpected server message type on the account screen: %v", msg.Type)
+	}
+	return session, nil
+}
+
+// ratingString - A player's rating, or a dash before their first rated game.
+func ratingString(stats messages.PlayerStats) string {
+	if stats.Rating == 0 {
+		return "-"
+	}
+	return fmt.Sprint(stats.Rating)
+}
+
+// SessionStateLeaderboard - The highest rated players at one game type.
+type SessionStateLeaderboard struct {
+	gameType game.GameType
+	entries  []messages.LeaderboardEntry
+	loaded   bool
+	//username picks out the logged in player's own row
+	username string
+}
+
+func NewSessionStateLeaderboard(gameType game.GameType, username string) *SessionStateLeaderboard {
+	return &SessionStateLeaderboard{gameType: gameType, username: username}
+}
+
+func (state SessionStateLeaderboard) GetType() SessionStateType {
+	return SessionStateTypeLeaderboard
+}
+
+func (state SessionStateLeaderboard) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		MarginBottom(1)
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#9CA3AF"))
+
+	ownStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B35")).
+		Bold(true)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1).
+		MarginTop(1)
+
+	title := titleStyle.Render("🏆 LEADERBOARD | ◀ " + state.gameType.String() + " ▶")
+	controls := controlsStyle.Render("←/→ Game • r Refresh • q Back to Menu")
+
+	if !state.loaded {
+		return lipgloss.JoinVertical(lipgloss.Left, title, statusStyle.Render("Loading..."), controls)
+	}
+	if len(state.entries) == 0 {
+		status := statusStyle.Render("No rated " + state.gameType.String() + " games yet. Play a quick match or a rated room to get on the board!")
+		return lipgloss.JoinVertical(lipgloss.Left, title, status, controls)
+	}
+
+	rowFormat := "%4s  %-16s %6s %5s %6s %5s"
+	rows := []string{headerStyle.Render(fmt.Sprintf(rowFormat, "RANK", "PLAYER", "RATING", "WINS", "LOSSES", "DRAWS"))}
+	for i, entry := range state.entries {
+		stats := entry.Stats
+		row := fmt.Sprintf(rowFormat, fmt.Sprint(i+1), entry.Username, fmt.Sprint(stats.Rating),
+			fmt.Sprint(stats.Wins), fmt.Sprint(stats.Losses), fmt.Sprint(stats.Draws))
+		if strings.EqualFold(entry.Username, state.username) {
+			row = ownStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(rows, "\n"), controls)
+}
+
+func (state *SessionStateLeaderboard) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	gameTypes := game.GetGameTypes()
+	switch msg.String() {
+	case "left", "h", "a":
+		if state.gameType == gameTypes[0] {
+			return session, nil
+		}
+		state.gameType--
+	case "right", "l", "d":
+		if state.gameType == gameTypes[len(gameTypes)-1] {
+			return session, nil
+		}
+		state.gameType++
+	case "r":
+		//asks again for the same game
+	case "q", "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	default:
+		return session, nil
+	}
+
+	state.loaded = false
+	return session, session.SendMsgToServer(messages.ClientMessage{
+		Type:     messages.ClientLeaderboard,
+		GameType: state.gameType,
+	})
+}
+
+func (state *SessionStateLeaderboard) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	switch msg.Type {
+	case messages.ServerLeaderboard:
+		//an answer for a game the player has since scrolled past is dropped
+		if msg.GameType == state.gameType {
+			state.entries = msg.Leaderboard
+			state.loaded = true
+		}
+	case messages.ServerError:
+		return session, errors.New(msg.ErrorMessage)
+	default:
+		return session, fmt.Errorf("unexpected server message type on the leaderboard: %v", msg.Type)
+	}
+	return session, nil
+}
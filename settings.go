@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wbarthol/ascii-arcade-2/internal/messages"
+)
+
+type SettingsOption int
+
+const (
+	SettingsOptionBell SettingsOption = iota
+	SettingsOptionNotification
+	SettingsOptionMuteEmotes
+	SettingsOptionTestNotification
+)
+
+func GetSettingsOptions() []SettingsOption {
+	return []SettingsOption{SettingsOptionBell, SettingsOptionNotification, SettingsOptionMuteEmotes, SettingsOptionTestNotification}
+}
+
+// SessionStateSettings - Client settings, saved to the config as soon as they are changed. Nothing
+// here needs a server.
+type SessionStateSettings struct {
+	cursor int
+	config Config
+}
+
+func NewSessionStateSettings(config Config) *SessionStateSettings {
+	return &SessionStateSettings{config: config}
+}
+
+func (state SessionStateSettings) GetType() SessionStateType {
+	return SessionStateTypeSettings
+}
+
+func (state SessionStateSettings) GetDisplayString() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1).
+		MarginBottom(1)
+
+	selectedStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#FAFAFA")).
+		Background(lipgloss.Color("#7D56F4")).
+		Padding(0, 1)
+
+	unselectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9CA3AF")).
+		Padding(0, 1)
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#626262")).
+		Italic(true).
+		MarginTop(1)
+
+	controlsStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#6B7280")).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#374151")).
+		Padding(1).
+		MarginTop(1)
+
+	checkbox := func(checked bool) string {
+		if checked {
+			return "[x]"
+		}
+		return "[ ]"
+	}
+
+	options := []string{}
+	for i, option := range GetSettingsOptions() {
+		optionStyle := unselectedStyle
+		prefix := "  "
+		if i == state.cursor {
+			optionStyle = selectedStyle
+			prefix = "▶ "
+		}
+
+		switch option {
+		case SettingsOptionBell:
+			options = append(options, optionStyle.Render(prefix+checkbox(state.config.Bell)+" Ring the terminal bell"))
+		case SettingsOptionNotification:
+			options = append(options, optionStyle.Render(prefix+"Desktop notification: ◀ "+state.config.Notification.String()+" ▶"))
+		case SettingsOptionMuteEmotes:
+			options = append(options, optionStyle.Render(prefix+checkbox(state.config.MuteEmotes)+" Mute the opponent's emotes"))
+		case SettingsOptionTestNotification:
+			options = append(options, optionStyle.Render(prefix+"Send a test notification"))
+		}
+	}
+
+	title := titleStyle.Render("⚙ SETTINGS")
+	hint := hintStyle.Render("The bell and notification go off when it becomes your turn, when someone joins your room\n" +
+		"and when a quick match is found. Terminals understand either OSC 9 or OSC 777, so try both.")
+	controls := controlsStyle.Render("↑/↓ Navigate • Enter/Space Toggle • ←/→ Change • q Back to Menu")
+	return lipgloss.JoinVertical(lipgloss.Left, title, lipgloss.JoinVertical(lipgloss.Left, options...), hint, controls)
+}
+
+func (state *SessionStateSettings) HandleUserInput(msg tea.KeyMsg, session Session) (tea.Model, tea.Cmd) {
+	styles := GetNotificationStyles()
+	option := GetSettingsOptions()[state.cursor]
+	switch msg.String() {
+	case "up", "w", "k":
+		if state.cursor > 0 {
+			state.cursor--
+		}
+		return session, nil
+	case "down", "s", "j":
+		if state.cursor < len(GetSettingsOptions())-1 {
+			state.cursor++
+		}
+		return session, nil
+	case "q", "esc":
+		return session.setState(SessionStateTypeInMenu), nil
+	case "left", "h", "a":
+		if option != SettingsOptionNotification || state.config.Notification == styles[0] {
+			return session, nil
+		}
+		state.config.Notification--
+	case "right", "l", "d":
+		if option != SettingsOptionNotification || state.config.Notification == styles[len(styles)-1] {
+			return session, nil
+		}
+		state.config.Notification++
+	case "enter", " ":
+		switch option {
+		case SettingsOptionBell:
+			state.config.Bell = !state.config.Bell
+		case SettingsOptionNotification:
+			state.config.Notification = styles[(int(state.config.Notification)+1)%len(styles)]
+		case SettingsOptionMuteEmotes:
+			state.config.MuteEmotes = !state.config.MuteEmotes
+		case SettingsOptionTestNotification:
+			if cmd := session.notify("Notifications are working"); cmd != nil {
+				return session, cmd
+			}
+			return session, func() tea.Msg { return ErrMsg{errors.New("Turn on the bell or a notification first.")} }
+		}
+	default:
+		return session, nil
+	}
+
+	session.config = state.config
+	if err := session.config.Save(); err != nil {
+		return session, func() tea.Msg { return ErrMsg{fmt.Errorf("could not save settings: %w", err)} }
+	}
+	return session, nil
+}
+
+func (state *SessionStateSettings) handleServerMessage(session Session, msg messages.ServerMessage) (Session, error) {
+	return session, fmt.Errorf("unexpected server message type in settings: %v", msg.Type)
+}
@@ -1,17 +1,40 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/wbarthol/ascii-arcade-2/internal/messages"
 )
 
+const (
+	//about 40 seconds of retrying in total, inside the server's default one minute grace period
+	reconnectAttempts  = 8
+	reconnectBaseDelay = 500 * time.Millisecond
+	reconnectMaxDelay  = 8 * time.Second
+)
+
+// WSDriver - Talks to the server over a websocket. If the connection drops while the player has a
+// seat in a room, it redials with backoff and resumes the seat with the token the room issued.
 type WSDriver struct {
-	wsOpen          bool
-	conn            *websocket.Conn
+	url             string
 	session         *Session
 	driverToSession chan messages.ServerMessage
+	driverStatus    chan bool
+	driverLatency   chan time.Duration
+
+	//mu guards the connection, which reconnecting swaps out, and the seat details used to resume
+	mu           sync.Mutex
+	conn         *websocket.Conn
+	wsOpen       bool
+	reconnecting bool
+	roomCode     string
+	resumeToken  string
+
+	done chan struct{}
 }
 
 func NewWS(url string, session *Session) (*WSDriver, error) {
@@ -21,44 +44,160 @@ func NewWS(url string, session *Session) (*WSDriver, error) {
 	}
 
 	ws := WSDriver{
+		url:             url,
 		wsOpen:          true,
 		conn:            conn,
 		session:         session,
 		driverToSession: session.driverToSession,
+		driverStatus:    session.driverStatus,
+		driverLatency:   session.driverLatency,
+		done:            make(chan struct{}),
 	}
+	ws.watchConnection(conn)
 
 	return &ws, nil
 }
 
 func (driver *WSDriver) WriteToServer(msg messages.ClientMessage) error {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	if driver.reconnecting {
+		return errors.New("reconnecting to the server, try again in a moment")
+	}
+	if msg.Type == messages.ClientJoinRoom {
+		driver.roomCode = msg.RoomCode
+	}
 	return driver.conn.WriteJSON(msg)
 }
 
+// Run - Reads server messages into the session until the driver is closed or the connection is
+// lost for good. Run is the only sender on driverToSession, so it is also the one to close it.
 func (driver *WSDriver) Run() {
+	defer close(driver.driverToSession)
+
 	for {
+		driver.mu.Lock()
+		conn := driver.conn
+		driver.mu.Unlock()
+
 		var msg messages.ServerMessage
-		err := driver.conn.ReadJSON(&msg)
-		// log.Printf("Message from server: %v\n", msg)
-		if !driver.wsOpen {
+		err := conn.ReadJSON(&msg)
+		if driver.isClosed() {
 			return
 		}
 		if err != nil {
-			//TODO add logger and debug mode
-			fmt.Printf("error reading message from server: %v\n", err)
-			driver.CloseWS()
+			if driver.canResume() && driver.reconnect() {
+				continue
+			}
+			driver.Close()
+			return
+		}
+
+		driver.trackSeat(msg)
+		select {
+		case driver.driverToSession <- msg:
+		case <-driver.done:
 			return
 		}
-		driver.driverToSession <- msg
 	}
 }
 
-func (driver *WSDriver) CloseWS() {
-	if driver == nil || !driver.wsOpen {
+// trackSeat - Keeps hold of the resume token for as long as the player has a seat to come back to.
+func (driver *WSDriver) trackSeat(msg messages.ServerMessage) {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+
+	switch msg.Type {
+	case messages.ServerRoomJoined, messages.ServerResumed:
+		driver.resumeToken = msg.ResumeToken
+		//quick match rooms get their code from the server rather than the player
+		if msg.RoomCode != "" {
+			driver.roomCode = msg.RoomCode
+		}
+	case messages.ServerRoomClosed, messages.ServerRoomDisconnected, messages.ServerResumeFailed:
+		driver.resumeToken = ""
+	}
+}
+
+func (driver *WSDriver) canResume() bool {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	return driver.resumeToken != ""
+}
+
+// reconnect - Redials with exponential backoff and asks the room for the seat back. The room
+// answers with a full resync, which Run then reads off the new connection like any other message.
+func (driver *WSDriver) reconnect() bool {
+	driver.setReconnecting(true)
+
+	delay := reconnectBaseDelay
+	for attempt := 0; attempt < reconnectAttempts; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-driver.done:
+			return false
+		}
+		delay = min(delay*2, reconnectMaxDelay)
+
+		conn, _, err := websocket.DefaultDialer.Dial(driver.url, nil)
+		if err != nil {
+			continue
+		}
+
+		driver.mu.Lock()
+		err = conn.WriteJSON(messages.ClientMessage{
+			Type:        messages.ClientResume,
+			RoomCode:    driver.roomCode,
+			ResumeToken: driver.resumeToken,
+		})
+		if err != nil || !driver.wsOpen {
+			driver.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		driver.conn.Close()
+		driver.conn = conn
+		driver.watchConnection(conn)
+		driver.mu.Unlock()
+
+		driver.setReconnecting(false)
+		return true
+	}
+
+	return false
+}
+
+// setReconnecting - Lets the session know to show or hide the reconnecting banner.
+func (driver *WSDriver) setReconnecting(reconnecting bool) {
+	driver.mu.Lock()
+	driver.reconnecting = reconnecting
+	driver.mu.Unlock()
+
+	select {
+	case driver.driverStatus <- reconnecting:
+	case <-driver.done:
+	}
+}
+
+func (driver *WSDriver) isClosed() bool {
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	return !driver.wsOpen
+}
+
+func (driver *WSDriver) Close() {
+	if driver == nil {
+		return
+	}
+
+	driver.mu.Lock()
+	defer driver.mu.Unlock()
+	if !driver.wsOpen {
 		return
 	}
 
-	
 	driver.wsOpen = false
+	close(driver.done)
 	driver.conn.Close()
-	close(driver.driverToSession)
 }